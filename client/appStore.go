@@ -1,155 +1,131 @@
 package client
 
 import (
+	"context"
 	"errors"
-
-	"github.com/mitchellh/mapstructure"
 )
 
-const GET_APP_STORE_LISTING = `
-  query GetAppStoreListing($id: ID!) {
-    app(id: $id) {
-      name
-      description
-      authorDisplay
-      image
-      ... on AppStoreWebApplication {
-        url
-      }
-    }
-  }
-`
-
-const DELETE_APP_STORE_LISTING = `
-  mutation DeleteAppStoreListing($id: ID!) {
-	deleteApp(id: $id)
-  }
-`
-
-const CREATE_APP_STORE_LISTING = `
-  mutation CreateAppStoreListing($input: CreateWebAppInput!) {
-    createWebApp(input: $input) {
-      id
-    }
-  }
-`
-
-const EDIT_APP_STORE_LISTING = `
-  mutation EditAppStoreListing($id: ID!, $edits: EditWebAppInput!) {
-    editWebApp(id: $id, edits: $edits) 
-  }
-`
+// The operations are defined in operations/app-store.graphql and
+// appStore_gen.go is generated from them, run `go generate` after changing
+// either the operations or the schema snapshot
+const (
+	GET_APP_STORE_LISTING    = GetAppStoreListingDocument
+	DELETE_APP_STORE_LISTING = DeleteAppStoreListingDocument
+	CREATE_APP_STORE_LISTING = CreateAppStoreListingDocument
+	EDIT_APP_STORE_LISTING   = EditAppStoreListingDocument
+)
 
 const GRAPHQL_URL = "/graphql"
 
 type AppStoreClient struct {
 	graphqlUrl string
 	client     graphqlClient
+	guard      IdempotencyGuard
 }
 
-type App struct {
-	Name          string
-	Description   string
-	AuthorDisplay string
-	Image         string
-	Url           string
-}
+type App = GetAppStoreListingApp
 
 func (self *AppStoreClient) Gql(query string, variables map[string]interface{}) (*map[string]interface{}, error) {
 	return self.client.Gql(self.graphqlUrl, query, variables)
 }
 
+func (self *AppStoreClient) GqlWithContext(ctx context.Context, query string, variables map[string]interface{}, options ...GqlOption) (*map[string]interface{}, error) {
+	return self.client.GqlWithContext(ctx, self.graphqlUrl, query, variables, options...)
+}
+
+func (self *AppStoreClient) GqlWithResponse(ctx context.Context, query string, variables map[string]interface{}, options ...GqlOption) (*GqlResponse, error) {
+	return self.client.GqlWithResponse(ctx, self.graphqlUrl, query, variables, options...)
+}
+
 func (self *AppStoreClient) GetAppStoreListing(id string) (*App, error) {
-	res, err := self.Gql(GET_APP_STORE_LISTING, map[string]interface{}{"id": id})
+	res, err := GetAppStoreListing(context.Background(), self, GetAppStoreListingVariables{Id: id})
 	if err != nil {
 		return nil, err
 	}
+	return &res.App, nil
+}
 
-	var data struct {
-		App App
-	}
-	err = mapstructure.Decode(res, &data)
-	if err != nil {
-		return nil, err
+// AppStoreCreate holds the fields of a web app listing, the product is
+// ProductLx when it is left empty
+type AppStoreCreate = CreateWebAppInput
+
+// editInput sends every field, so fields left empty are cleared
+func (params AppStoreCreate) editInput() EditWebAppInput {
+	return EditWebAppInput{
+		Name:          Some(params.Name),
+		AuthorDisplay: Some(params.AuthorDisplay),
+		Url:           Some(params.Url),
+		Description:   Some(params.Description),
+		Image:         Some(params.Image),
 	}
-	return &data.App, nil
 }
 
-type AppStoreCreate struct {
-	Name          string
-	AuthorDisplay string
-	Url           string
-	Description   string
-	Image         string
+// AppStoreEdit holds the fields to change with UpdateAppStoreListing, nil
+// fields are left as they are
+type AppStoreEdit struct {
+	Name          *string
+	AuthorDisplay *string
+	Url           *string
+	Description   *string
+	Image         *string
 }
 
 func (self *AppStoreClient) CreateAppStoreListing(params AppStoreCreate) (*string, error) {
-	res, err := self.Gql(CREATE_APP_STORE_LISTING, map[string]interface{}{"input": map[string]string{
-		"name":          params.Name,
-		"authorDisplay": params.AuthorDisplay,
-		"url":           params.Url,
-		"description":   params.Description,
-		"image":         params.Image,
-		"product":       "LX",
-	}})
-	if err != nil {
-		return nil, err
+	return self.CreateAppStoreListingWithContext(context.Background(), params)
+}
+
+// CreateAppStoreListingWithContext sends the idempotency key of ctx, see
+// WithIdempotencyKey, with the create. With an IdempotencyGuard a listing
+// created with the same key is returned instead of creating a new one.
+func (self *AppStoreClient) CreateAppStoreListingWithContext(ctx context.Context, params AppStoreCreate) (*string, error) {
+	if params.Product == "" {
+		params.Product = ProductLx
 	}
-	var data struct {
-		CreateWebApp struct {
-			Id string
+	key, _ := IdempotencyKeyFromContext(ctx)
+	return createOnce(ctx, self.guard, IDEMPOTENCY_KIND_APP_STORE_LISTING, key, func(ctx context.Context) (*string, error) {
+		res, err := CreateAppStoreListing(ctx, self, CreateAppStoreListingVariables{Input: params})
+		if err != nil {
+			return nil, err
 		}
-	}
-	err = mapstructure.Decode(res, &data)
-	if err != nil {
-		return nil, err
-	}
-	return &data.CreateWebApp.Id, nil
+		return &res.CreateWebApp.Id, nil
+	})
 }
 
 func (self *AppStoreClient) EditAppStoreListing(id string, params AppStoreCreate) error {
-	res, err := self.Gql(EDIT_APP_STORE_LISTING, map[string]interface{}{
-		"id": id,
-		"edits": map[string]string{
-			"name":          params.Name,
-			"authorDisplay": params.AuthorDisplay,
-			"url":           params.Url,
-			"description":   params.Description,
-			"image":         params.Image,
-		}})
-	if err != nil {
-		return err
-	}
+	return self.editAppStoreListing(id, params.editInput())
+}
 
-	var data struct {
-		EditWebApp bool
-	}
-	err = mapstructure.Decode(res, &data)
+// UpdateAppStoreListing changes only the fields set in edits
+func (self *AppStoreClient) UpdateAppStoreListing(id string, edits AppStoreEdit) error {
+	return self.editAppStoreListing(id, EditWebAppInput{
+		Name:          FromPointer(edits.Name),
+		AuthorDisplay: FromPointer(edits.AuthorDisplay),
+		Url:           FromPointer(edits.Url),
+		Description:   FromPointer(edits.Description),
+		Image:         FromPointer(edits.Image),
+	})
+}
+
+func (self *AppStoreClient) editAppStoreListing(id string, edits EditWebAppInput) error {
+	res, err := EditAppStoreListing(context.Background(), self, EditAppStoreListingVariables{
+		Id:    id,
+		Edits: edits,
+	})
 	if err != nil {
 		return err
 	}
-	if !data.EditWebApp {
+	if !res.EditWebApp {
 		return errors.New("The app you're trying to edit does not exist")
 	}
 	return nil
 }
 
 func (self *AppStoreClient) DeleteAppStoreListing(id string) error {
-	res, err := self.Gql(DELETE_APP_STORE_LISTING, map[string]interface{}{
-		"id": id,
-	})
-	if err != nil {
-		return err
-	}
-
-	var data struct {
-		DeleteApp bool
-	}
-	err = mapstructure.Decode(res, &data)
+	res, err := DeleteAppStoreListing(context.Background(), self, DeleteAppStoreListingVariables{Id: id})
 	if err != nil {
 		return err
 	}
-	if !data.DeleteApp {
+	if !res.DeleteApp {
 		return errors.New("The app you're trying to delete does not exist")
 	}
 	return nil
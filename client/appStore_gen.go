@@ -0,0 +1,185 @@
+// Code generated by phc-codegen. DO NOT EDIT.
+
+package client
+
+import (
+	"context"
+)
+
+// GetAppStoreListingDocument is the query GetAppStoreListing
+const GetAppStoreListingDocument = `
+query GetAppStoreListing ($id: ID!) {
+  app(id: $id) {
+    name
+    description
+    authorDisplay
+    image
+    ... on AppStoreWebApplication {
+      url
+    }
+  }
+}
+`
+
+type GetAppStoreListingVariables struct {
+	Id string `json:"id"`
+}
+
+type GetAppStoreListingResponse struct {
+	App GetAppStoreListingApp `json:"app"`
+}
+
+type GetAppStoreListingApp struct {
+	Name          string `json:"name"`
+	Description   string `json:"description"`
+	AuthorDisplay string `json:"authorDisplay"`
+	Image         string `json:"image"`
+	Url           string `json:"url"`
+}
+
+// GetAppStoreListing runs the query GetAppStoreListing
+func GetAppStoreListing(ctx context.Context, client Querier, variables GetAppStoreListingVariables) (*GetAppStoreListingResponse, error) {
+	vars, err := StructToVariables(variables)
+	if err != nil {
+		return nil, err
+	}
+	res, err := client.GqlWithContext(ctx, GetAppStoreListingDocument, vars)
+	if err != nil {
+		return nil, err
+	}
+	var response GetAppStoreListingResponse
+	err = DecodeData(res, &response)
+	if err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// DeleteAppStoreListingDocument is the mutation DeleteAppStoreListing
+const DeleteAppStoreListingDocument = `
+mutation DeleteAppStoreListing ($id: ID!) {
+  deleteApp(id: $id)
+}
+`
+
+type DeleteAppStoreListingVariables struct {
+	Id string `json:"id"`
+}
+
+type DeleteAppStoreListingResponse struct {
+	DeleteApp bool `json:"deleteApp"`
+}
+
+// DeleteAppStoreListing runs the mutation DeleteAppStoreListing
+func DeleteAppStoreListing(ctx context.Context, client Querier, variables DeleteAppStoreListingVariables) (*DeleteAppStoreListingResponse, error) {
+	vars, err := StructToVariables(variables)
+	if err != nil {
+		return nil, err
+	}
+	res, err := client.GqlWithContext(ctx, DeleteAppStoreListingDocument, vars)
+	if err != nil {
+		return nil, err
+	}
+	var response DeleteAppStoreListingResponse
+	err = DecodeData(res, &response)
+	if err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// CreateAppStoreListingDocument is the mutation CreateAppStoreListing
+const CreateAppStoreListingDocument = `
+mutation CreateAppStoreListing ($input: CreateWebAppInput!) {
+  createWebApp(input: $input) {
+    id
+  }
+}
+`
+
+type CreateAppStoreListingVariables struct {
+	Input CreateWebAppInput `json:"input"`
+}
+
+type CreateAppStoreListingResponse struct {
+	CreateWebApp CreateAppStoreListingCreateWebApp `json:"createWebApp"`
+}
+
+type CreateAppStoreListingCreateWebApp struct {
+	Id string `json:"id"`
+}
+
+// CreateAppStoreListing runs the mutation CreateAppStoreListing
+func CreateAppStoreListing(ctx context.Context, client Querier, variables CreateAppStoreListingVariables) (*CreateAppStoreListingResponse, error) {
+	vars, err := StructToVariables(variables)
+	if err != nil {
+		return nil, err
+	}
+	res, err := client.GqlWithContext(ctx, CreateAppStoreListingDocument, vars)
+	if err != nil {
+		return nil, err
+	}
+	var response CreateAppStoreListingResponse
+	err = DecodeData(res, &response)
+	if err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// EditAppStoreListingDocument is the mutation EditAppStoreListing
+const EditAppStoreListingDocument = `
+mutation EditAppStoreListing ($id: ID!, $edits: EditWebAppInput!) {
+  editWebApp(id: $id, edits: $edits)
+}
+`
+
+type EditAppStoreListingVariables struct {
+	Id    string          `json:"id"`
+	Edits EditWebAppInput `json:"edits"`
+}
+
+type EditAppStoreListingResponse struct {
+	EditWebApp bool `json:"editWebApp"`
+}
+
+// EditAppStoreListing runs the mutation EditAppStoreListing
+func EditAppStoreListing(ctx context.Context, client Querier, variables EditAppStoreListingVariables) (*EditAppStoreListingResponse, error) {
+	vars, err := StructToVariables(variables)
+	if err != nil {
+		return nil, err
+	}
+	res, err := client.GqlWithContext(ctx, EditAppStoreListingDocument, vars)
+	if err != nil {
+		return nil, err
+	}
+	var response EditAppStoreListingResponse
+	err = DecodeData(res, &response)
+	if err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+type CreateWebAppInput struct {
+	Name          string  `json:"name"`
+	AuthorDisplay string  `json:"authorDisplay"`
+	Url           string  `json:"url"`
+	Description   string  `json:"description"`
+	Image         string  `json:"image"`
+	Product       Product `json:"product"`
+}
+
+type EditWebAppInput struct {
+	Name          Optional[string] `json:"name"`
+	AuthorDisplay Optional[string] `json:"authorDisplay"`
+	Url           Optional[string] `json:"url"`
+	Description   Optional[string] `json:"description"`
+	Image         Optional[string] `json:"image"`
+}
+
+type Product string
+
+const (
+	ProductLx Product = "LX"
+)
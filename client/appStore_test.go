@@ -38,3 +38,19 @@ func TestGetAppStoreListing(t *testing.T) {
 		t.Fatal("Did not get back currect response", response)
 	}
 }
+
+func TestUpdateAppStoreListingSendsOnlySetFields(t *testing.T) {
+	mockResponse := map[string]interface{}{"editWebApp": true}
+	mockClient := MockClient{
+		response: &mockResponse,
+	}
+	client := AppStoreClient{client: &mockClient}
+	name := "New name"
+	if err := client.UpdateAppStoreListing("some_app_id", AppStoreEdit{Name: &name}); err != nil {
+		t.Fatal("Unexpected error", err)
+	}
+	edits := mockClient.variables[0]["edits"].(map[string]interface{})
+	if len(edits) != 1 || edits["name"] != "New name" {
+		t.Fatal("Should only send the fields that are set", edits)
+	}
+}
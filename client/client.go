@@ -3,15 +3,20 @@ package client
 import (
 	"bytes"
 	"context"
+	"encoding/base64"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io/ioutil"
-	"log"
 	"net/http"
 	"strings"
+	"time"
+	"unicode/utf8"
 
+	"github.com/aws/aws-sdk-go-v2/aws/retry"
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/service/lambda"
+	"golang.org/x/sync/singleflight"
 )
 
 type payload struct {
@@ -20,6 +25,7 @@ type payload struct {
 	HttpMethod            string            `json:"httpMethod"`
 	QueryStringParameters map[string]string `json:"queryStringParameters"`
 	Body                  string            `json:"body"`
+	IsBase64Encoded       bool              `json:"isBase64Encoded,omitempty"`
 }
 
 type policy struct {
@@ -30,6 +36,8 @@ type responsePayload struct {
 	Body       string            `json:"body"`
 	StatusCode int               `json:"statusCode"`
 	Headers    map[string]string `json:"headers"`
+	// IsBase64Encoded is set for binary bodies
+	IsBase64Encoded bool `json:"isBase64Encoded"`
 }
 
 func toHeader(header map[string]string) http.Header {
@@ -56,6 +64,46 @@ type LambdaClient struct {
 	account string
 	user    string
 	rules   map[string]bool
+
+	// Identical read queries that are in flight at the same time share a
+	// single invocation, mutations are always sent on their own
+	inflight          singleflight.Group
+	disableCoalescing bool
+
+	stats   Stats
+	metrics []MetricsRecorder
+
+	logger   Logger
+	redactor Redactor
+
+	dryRun *DryRun
+	guard  IdempotencyGuard
+
+	validateImages bool
+	httpClient     *http.Client
+
+	validators map[string]OperationValidator
+
+	moduleSources moduleSourceRegistry
+}
+
+// OperationValidator checks an operation before it is sent, see
+// graphql.Validator for a validator backed by a schema snapshot
+type OperationValidator interface {
+	Validate(query string, variables map[string]interface{}) error
+}
+
+// namedOperationValidator is implemented by validators that can check the
+// variables of one operation in a document defining several
+type namedOperationValidator interface {
+	ValidateOperation(query string, operationName string, variables map[string]interface{}) error
+}
+
+func validateOperation(validator OperationValidator, query string, operationName string, variables map[string]interface{}) error {
+	if named, ok := validator.(namedOperationValidator); ok && operationName != "" {
+		return named.ValidateOperation(query, operationName, variables)
+	}
+	return validator.Validate(query, variables)
 }
 
 func (c *LambdaClient) buildHeaders() map[string]string {
@@ -70,14 +118,29 @@ func (c *LambdaClient) buildHeaders() map[string]string {
 	}
 }
 
-func (c *LambdaClient) buildGqlQuery(path string, query string, variables map[string]interface{}) []byte {
+func (c *LambdaClient) buildGqlQuery(ctx context.Context, path string, query string, operation operationDefinition, variables map[string]interface{}) ([]byte, error) {
 	type Body struct {
-		Query     string                 `json:"query"`
-		Variables map[string]interface{} `json:"variables"`
+		Query         string                 `json:"query"`
+		OperationName string                 `json:"operationName,omitempty"`
+		Variables     map[string]interface{} `json:"variables"`
+	}
+	body, err := json.Marshal(&Body{Query: query, OperationName: operation.Name, Variables: variables})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal GraphQL body: %w", err)
+	}
+	headers := c.buildHeaders()
+	if operation.Kind == "mutation" {
+		key, ok := IdempotencyKeyFromContext(ctx)
+		if !ok {
+			key, err = NewIdempotencyKey()
+			if err != nil {
+				return nil, err
+			}
+		}
+		headers[IDEMPOTENCY_KEY_HEADER] = key
 	}
-	body, _ := json.Marshal(&Body{Query: query, Variables: variables})
 	payload := &payload{
-		Headers:               c.buildHeaders(),
+		Headers:               headers,
 		HttpMethod:            "POST",
 		QueryStringParameters: map[string]string{},
 		Path:                  path,
@@ -85,9 +148,9 @@ func (c *LambdaClient) buildGqlQuery(path string, query string, variables map[st
 	}
 	bytes, err := json.Marshal(payload)
 	if err != nil {
-		log.Fatalf("Failed to marshall payload %v", err)
+		return nil, fmt.Errorf("failed to marshal payload: %w", err)
 	}
-	return bytes
+	return bytes, nil
 }
 
 func parseUri(uri string) (*string, *string, error) {
@@ -101,36 +164,189 @@ func parseUri(uri string) (*string, *string, error) {
 }
 
 func (c *LambdaClient) Gql(uri string, query string, variables map[string]interface{}) (*map[string]interface{}, error) {
+	return c.GqlWithContext(context.Background(), uri, query, variables)
+}
+
+// GqlWithContext is Gql with a context for the invocation. Mutations are
+// sent with the idempotency key from the context, see WithIdempotencyKey, and
+// documents with several operations run the one selected with WithOperation.
+func (c *LambdaClient) GqlWithContext(ctx context.Context, uri string, query string, variables map[string]interface{}, options ...GqlOption) (*map[string]interface{}, error) {
+	response, err := c.GqlWithResponse(ctx, uri, query, variables, options...)
+	if err != nil {
+		return nil, err
+	}
+	return &response.Data, nil
+}
+
+// GqlWithResponse is GqlWithContext returning the full response with its
+// extensions, status code and headers. When the response has GraphQL errors
+// it is returned together with the first of them as the error.
+func (c *LambdaClient) GqlWithResponse(ctx context.Context, uri string, query string, variables map[string]interface{}, options ...GqlOption) (*GqlResponse, error) {
 	functionName, path, err := parseUri(uri)
 	if err != nil {
 		return nil, err
 	}
-	resp, err := c.invoker.Invoke(context.Background(), &lambda.InvokeInput{
-		FunctionName: functionName,
-		Payload:      c.buildGqlQuery(*path, query, variables),
-	})
+	operation, err := selectOperation(query, applyGqlOptions(options).operationName)
+	if err != nil {
+		return nil, err
+	}
+	if validator, ok := c.validators[uri]; ok {
+		if err := validateOperation(validator, query, operation.Name, variables); err != nil {
+			return nil, fmt.Errorf("invalid operation for %s: %w", uri, err)
+		}
+	}
+	mutation := operation.Kind == "mutation"
+	request, err := c.buildGqlQuery(ctx, *path, query, operation, variables)
+	if err != nil {
+		return nil, err
+	}
+	invocation := invocationRequest{
+		functionName: *functionName,
+		path:         *path,
+		operation:    operation.label(),
+		graphql:      true,
+		payload:      request,
+	}
+	if c.dryRun != nil && mutation {
+		data := c.dryRun.planGql(invocation, query, operation.Name, variables)
+		return &GqlResponse{Data: data, StatusCode: http.StatusOK, Headers: http.Header{}}, nil
+	}
 
+	var response *GqlResponse
+	if hasUploads(variables) {
+		response, err = c.gqlUpload(ctx, uri, query, operation, variables)
+	} else {
+		response, err = c.gqlInvoke(ctx, invocation, mutation)
+	}
+	if err != nil {
+		return nil, err
+	}
+	c.logExtensions(invocation.operation, response)
+	return response, response.Err()
+}
+
+func (c *LambdaClient) gqlInvoke(ctx context.Context, invocation invocationRequest, mutation bool) (*GqlResponse, error) {
+	raw, err := c.invokeGql(ctx, invocation, mutation)
 	if err != nil {
 		return nil, err
 	}
 	var payload responsePayload
-	err = json.Unmarshal(resp.Payload, &payload)
+	err = json.Unmarshal(raw, &payload)
 	if err != nil {
 		return nil, err
 	}
+	return decodeGqlResponse(payload.StatusCode, toHeader(payload.Headers), []byte(payload.Body))
+}
+
+type invocationRequest struct {
+	functionName string
+	path         string
+	operation    string
+	graphql      bool
+	payload      []byte
+}
+
+// invoke sends the payload to the lambda and records metrics for the
+// invocation
+func (c *LambdaClient) invoke(ctx context.Context, request invocationRequest) ([]byte, error) {
+	c.logRequest(request)
+	start := time.Now()
+	resp, err := c.invoker.Invoke(ctx, &lambda.InvokeInput{
+		FunctionName: &request.functionName,
+		Payload:      request.payload,
+	})
+
+	invocation := Invocation{
+		Labels: MetricLabels{
+			FunctionName: request.functionName,
+			Path:         request.path,
+			Operation:    request.operation,
+		},
+		Duration:     time.Since(start),
+		RequestBytes: len(request.payload),
+	}
+	if err != nil {
+		invocation.ErrorClass = ERROR_CLASS_INVOKE
+		var exhausted *retry.MaxAttemptsError
+		if errors.As(err, &exhausted) && exhausted.Attempt > 1 {
+			invocation.Retries = exhausted.Attempt - 1
+		}
+	} else {
+		invocation.ResponseBytes = len(resp.Payload)
+		invocation.ErrorClass = classifyResponse(resp.Payload, resp.FunctionError, request.graphql)
+		if attempts, ok := retry.GetAttemptResults(resp.ResultMetadata); ok && len(attempts.Results) > 1 {
+			invocation.Retries = len(attempts.Results) - 1
+		}
+	}
+	c.stats.RecordInvocation(invocation)
+	for _, recorder := range c.metrics {
+		recorder.RecordInvocation(invocation)
+	}
 
-	var body responseBody
-	err = json.Unmarshal([]byte(payload.Body), &body)
 	if err != nil {
+		c.logResponse(invocation, nil, err)
 		return nil, err
 	}
-	if len(body.Errors) > 0 {
-		return nil, errors.New(body.Errors[0].Message)
+	c.logResponse(invocation, resp.Payload, nil)
+	return resp.Payload, nil
+}
+
+// invokeGql invokes the lambda and returns the raw response payload. Each
+// caller decodes the payload itself so coalesced callers never share the
+// resulting maps.
+func (c *LambdaClient) invokeGql(ctx context.Context, request invocationRequest, mutation bool) ([]byte, error) {
+	if c.disableCoalescing || mutation {
+		return c.invoke(ctx, request)
+	}
+
+	// The payload already holds the identity headers, path, query and
+	// variables, and encoding/json sorts map keys, so identical requests
+	// always produce the same key
+	key := request.functionName + "\x00" + string(request.payload)
+	// The shared invocation must not fail for every caller when the caller
+	// that started it gives up, so it runs on a detached context and each
+	// caller only waits for as long as its own context allows
+	shared := c.inflight.DoChan(key, func() (interface{}, error) {
+		sharedCtx, cancel := context.WithTimeout(detachedContext{ctx}, COALESCED_INVOCATION_TIMEOUT)
+		defer cancel()
+		return c.invoke(sharedCtx, request)
+	})
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case result := <-shared:
+		if result.Err != nil {
+			return nil, result.Err
+		}
+		return result.Val.([]byte), nil
 	}
-	return &body.Data, nil
+}
+
+// COALESCED_INVOCATION_TIMEOUT bounds a shared invocation, which isn't
+// cancelled by the contexts of its callers. It is the longest a lambda can
+// run.
+const COALESCED_INVOCATION_TIMEOUT = 15 * time.Minute
+
+// detachedContext keeps the values of its parent without its deadline and
+// cancellation
+type detachedContext struct {
+	parent context.Context
+}
+
+func (detachedContext) Deadline() (time.Time, bool) { return time.Time{}, false }
+func (detachedContext) Done() <-chan struct{}       { return nil }
+func (detachedContext) Err() error                  { return nil }
+func (c detachedContext) Value(key interface{}) interface{} {
+	return c.parent.Value(key)
 }
 
 func (c *LambdaClient) Do(req *http.Request) (*http.Response, error) {
+	return c.do(req, "")
+}
+
+// do sends the request, operation labels the invocation in logs and metrics
+// and is taken from the body of GraphQL requests when it is empty
+func (c *LambdaClient) do(req *http.Request, operation string) (*http.Response, error) {
 	functionName, path, err := parseUri(req.URL.String())
 	if err != nil {
 		return nil, err
@@ -140,32 +356,57 @@ func (c *LambdaClient) Do(req *http.Request) (*http.Response, error) {
 	// go http.Header type doesn't align with the lambda header type
 	// so we just take the first value of the request header
 	headers := c.buildHeaders()
+	if req.Header.Get("Content-Type") != "" {
+		delete(headers, "content-type")
+	}
 	for k, v := range req.Header {
 		if _, ok := headers[k]; !ok {
 			headers[k] = v[0]
 		}
 	}
+	if key, ok := IdempotencyKeyFromContext(req.Context()); ok && req.Header.Get(IDEMPOTENCY_KEY_HEADER) == "" {
+		headers[IDEMPOTENCY_KEY_HEADER] = key
+	}
 
-	body, err := ioutil.ReadAll(req.Body)
-	if err != nil {
-		return nil, err
+	var body []byte
+	if req.Body != nil {
+		body, err = ioutil.ReadAll(req.Body)
+		if err != nil {
+			return nil, err
+		}
 	}
 
-	data, err := json.Marshal(payload{
+	request := payload{
 		Headers:               headers,
 		HttpMethod:            req.Method,
 		QueryStringParameters: map[string]string{},
 		Path:                  *path,
 		Body:                  string(body),
-	})
+	}
+	// Binary bodies such as multipart uploads can't be sent as a JSON string
+	if !utf8.Valid(body) {
+		request.Body = base64.StdEncoding.EncodeToString(body)
+		request.IsBase64Encoded = true
+	}
+	data, err := json.Marshal(request)
 	if err != nil {
 		return nil, err
 	}
 
-	lambdaResponse, err := c.invoker.Invoke(req.Context(), &lambda.InvokeInput{
-		FunctionName: functionName,
-		Payload:      data,
-	})
+	if operation == "" {
+		operation = operationFromBody(body)
+	}
+	invocation := invocationRequest{
+		functionName: *functionName,
+		path:         *path,
+		operation:    operation,
+		graphql:      false,
+		payload:      data,
+	}
+	if c.dryRun != nil && req.Method != http.MethodGet && req.Method != http.MethodHead {
+		return c.dryRun.planDo(invocation, req.Method, body), nil
+	}
+	lambdaResponse, err := c.invoke(req.Context(), invocation)
 
 	if err != nil {
 		return nil, err
@@ -173,13 +414,21 @@ func (c *LambdaClient) Do(req *http.Request) (*http.Response, error) {
 
 	// attempt to convert lambda response into http Response
 	var respPayload responsePayload
-	err = json.Unmarshal(lambdaResponse.Payload, &respPayload)
+	err = json.Unmarshal(lambdaResponse, &respPayload)
 	if err != nil {
 		return nil, err
 	}
 
+	responseBody := []byte(respPayload.Body)
+	if respPayload.IsBase64Encoded {
+		responseBody, err = base64.StdEncoding.DecodeString(respPayload.Body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode base64 response body: %w", err)
+		}
+	}
+
 	resp := http.Response{
-		Body:       ioutil.NopCloser(bytes.NewBufferString(respPayload.Body)),
+		Body:       ioutil.NopCloser(bytes.NewBuffer(responseBody)),
 		StatusCode: respPayload.StatusCode,
 		Header:     toHeader(respPayload.Headers),
 	}
@@ -187,25 +436,45 @@ func (c *LambdaClient) Do(req *http.Request) (*http.Response, error) {
 	return &resp, nil
 }
 
+// Stats returns a snapshot of the invocations made by this client, grouped by
+// function name, path and GraphQL operation
+func (c *LambdaClient) Stats() []OperationStats {
+	return c.stats.Snapshot()
+}
+
+const (
+	APP_STORE_GRAPHQL_URL   = "app-store-service:deployed/graphql"
+	MARKETPLACE_GRAPHQL_URL = "marketplace-service:deployed/v1/marketplace/authenticated/graphql"
+)
+
 func (c *LambdaClient) AppStore() AppStoreClient {
 	return AppStoreClient{
 		client:     c,
-		graphqlUrl: "app-store-service:deployed/graphql",
+		graphqlUrl: APP_STORE_GRAPHQL_URL,
+		guard:      c.guard,
 	}
 }
 
 func (c *LambdaClient) Marketplace() MarketplaceClient {
 	return MarketplaceClient{
-		client:     c,
-		graphqlUrl: "marketplace-service:deployed/v1/marketplace/authenticated/graphql",
+		client:         c,
+		graphqlUrl:     MARKETPLACE_GRAPHQL_URL,
+		guard:          c.guard,
+		validateImages: c.validateImages,
+		dryRun:         c.dryRun != nil,
+		httpClient:     c.httpClient,
+		moduleSources:  &c.moduleSources,
 	}
 }
 
-func BuildClient(account string, user string, rules map[string]bool) (*LambdaClient, error) {
+func BuildClient(account string, user string, rules map[string]bool, options ...Option) (*LambdaClient, error) {
 	cfg, err := config.LoadDefaultConfig(context.Background())
 	if err != nil {
 		return nil, err
 	}
-	client := LambdaClient{invoker: lambda.NewFromConfig(cfg), user: user, rules: rules, account: account}
-	return &client, nil
+	client := &LambdaClient{invoker: lambda.NewFromConfig(cfg), user: user, rules: rules, account: account}
+	for _, option := range options {
+		option(client)
+	}
+	return client, nil
 }
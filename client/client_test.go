@@ -7,7 +7,10 @@ import (
 	"io/ioutil"
 	"net/http"
 	"net/url"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/service/lambda"
 )
@@ -26,9 +29,12 @@ func TestBuildGqlQuery(t *testing.T) {
 			"testRule": true,
 		},
 	}
-	raw := client.buildGqlQuery("/some/path", MOCK_MUTATION, map[string]interface{}{"var": "value"})
+	raw, err := client.buildGqlQuery(context.Background(), "/some/path", MOCK_MUTATION, operationDefinition{Kind: "mutation", Name: "MockMutation"}, map[string]interface{}{"var": "value"})
+	if err != nil {
+		t.Fatal("Unexpected error", err)
+	}
 	var parsed map[string]interface{}
-	err := json.Unmarshal(raw, &parsed)
+	err = json.Unmarshal(raw, &parsed)
 	if err != nil {
 		t.Fatal("Could not parse payload as json", string(raw))
 	}
@@ -40,6 +46,9 @@ func TestBuildGqlQuery(t *testing.T) {
 	if parsedBody["query"].(string) != MOCK_MUTATION {
 		t.Fatal("Missing query in body", parsed["body"])
 	}
+	if parsedBody["operationName"] != "MockMutation" {
+		t.Fatal("Missing operation name in body", parsed["body"])
+	}
 	variables := parsedBody["variables"].(map[string]interface{})
 	if variables["var"] != "value" {
 		t.Fatal("Missing variable", variables)
@@ -182,3 +191,141 @@ func TestDo(t *testing.T) {
 	}
 
 }
+
+type BlockingInvoker struct {
+	calls   int32
+	release chan struct{}
+}
+
+func (m *BlockingInvoker) Invoke(ctx context.Context, payload *lambda.InvokeInput, rest ...func(*lambda.Options)) (*lambda.InvokeOutput, error) {
+	atomic.AddInt32(&m.calls, 1)
+	<-m.release
+	return &lambda.InvokeOutput{
+		Payload: []byte("{ \"body\": \"{ \\\"data\\\": { \\\"result\\\": true }}\"}"),
+	}, nil
+}
+
+const MOCK_QUERY = `
+query MockQuery($var: String!) {
+	some_query(var: $var) {
+		result
+	}
+}
+`
+
+func runConcurrently(t *testing.T, client *LambdaClient, mock *BlockingInvoker, query string, callers int) {
+	var wg sync.WaitGroup
+	results := make([]*map[string]interface{}, callers)
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			res, err := client.Gql("some_lambda:status/some/path", query, map[string]interface{}{"var": "value"})
+			if err != nil {
+				t.Error("Unexpected test Error", err)
+				return
+			}
+			results[i] = res
+		}(i)
+	}
+	// Give every goroutine a chance to reach the invoker before releasing it
+	time.Sleep(50 * time.Millisecond)
+	close(mock.release)
+	wg.Wait()
+	for _, res := range results {
+		if res == nil || !(*res)["result"].(bool) {
+			t.Fatal("Did not return data to every caller", res)
+		}
+	}
+}
+
+func TestGqlCoalescesQueries(t *testing.T) {
+	mock := BlockingInvoker{release: make(chan struct{})}
+	client := LambdaClient{invoker: &mock}
+	runConcurrently(t, &client, &mock, MOCK_QUERY, 5)
+	if mock.calls != 1 {
+		t.Fatal("Expected identical queries to share one invocation", mock.calls)
+	}
+}
+
+func TestGqlCoalescedCallersKeepTheirOwnContext(t *testing.T) {
+	mock := BlockingInvoker{release: make(chan struct{})}
+	client := LambdaClient{invoker: &mock}
+	uri := "some_lambda:status/some/path"
+	variables := map[string]interface{}{"var": "value"}
+
+	leaderCtx, cancel := context.WithCancel(context.Background())
+	leaderErr := make(chan error)
+	go func() {
+		_, err := client.GqlWithContext(leaderCtx, uri, MOCK_QUERY, variables)
+		leaderErr <- err
+	}()
+	time.Sleep(20 * time.Millisecond)
+	follower := make(chan *map[string]interface{})
+	go func() {
+		res, err := client.Gql(uri, MOCK_QUERY, variables)
+		if err != nil {
+			t.Error("Unexpected test Error", err)
+		}
+		follower <- res
+	}()
+	time.Sleep(20 * time.Millisecond)
+
+	cancel()
+	if err := <-leaderErr; err != context.Canceled {
+		t.Fatal("Expected the cancelled caller to stop waiting", err)
+	}
+	close(mock.release)
+	res := <-follower
+	if res == nil || !(*res)["result"].(bool) {
+		t.Fatal("Did not return data to the caller that kept waiting", res)
+	}
+	if mock.calls != 1 {
+		t.Fatal("Expected identical queries to share one invocation", mock.calls)
+	}
+}
+
+func TestGqlDoesNotCoalesceMutations(t *testing.T) {
+	mock := BlockingInvoker{release: make(chan struct{})}
+	client := LambdaClient{invoker: &mock}
+	runConcurrently(t, &client, &mock, MOCK_MUTATION, 5)
+	if mock.calls != 5 {
+		t.Fatal("Expected every mutation to be invoked", mock.calls)
+	}
+}
+
+func TestGqlSelectsOperation(t *testing.T) {
+	mock := MockInvoker{
+		response: &lambda.InvokeOutput{
+			Payload: []byte("{ \"body\": \"{ \\\"data\\\": { \\\"result\\\": true }}\"}"),
+		},
+	}
+	client := LambdaClient{invoker: &mock}
+	document := GET_PUBLISHED_APP_TILE_MODULE + MOCK_MUTATION
+
+	_, err := client.Gql("some_lambda:status/some/path", document, nil)
+	if err == nil || mock.hasBeenCalled {
+		t.Fatal("Expected ambiguous documents to be rejected before invoking", err)
+	}
+
+	_, err = client.GqlWithContext(context.Background(), "some_lambda:status/some/path", document, map[string]interface{}{"var": "value"}, WithOperation("MockMutation"))
+	if err != nil {
+		t.Fatal("Unexpected error", err)
+	}
+	var sent payload
+	if err := json.Unmarshal(mock.payload.Payload, &sent); err != nil {
+		t.Fatal("Could not parse payload", err)
+	}
+	var body struct {
+		OperationName string
+	}
+	if err := json.Unmarshal([]byte(sent.Body), &body); err != nil {
+		t.Fatal("Could not parse body", err)
+	}
+	if body.OperationName != "MockMutation" {
+		t.Fatal("Did not send the operation name", sent.Body)
+	}
+	if sent.Headers[IDEMPOTENCY_KEY_HEADER] == "" {
+		t.Fatal("The selected mutation should be sent with an idempotency key", sent.Headers)
+	}
+}
@@ -0,0 +1,131 @@
+package client
+
+import (
+	"context"
+	"fmt"
+)
+
+// DraftStep is a step of creating and publishing a module
+type DraftStep string
+
+const (
+	DRAFT_STEP_CREATE  DraftStep = "create"
+	DRAFT_STEP_SOURCE  DraftStep = "source"
+	DRAFT_STEP_IMAGE   DraftStep = "image"
+	DRAFT_STEP_PUBLISH DraftStep = "publish"
+)
+
+// PartialDraftError is returned when a step fails after the draft module was
+// created. Unless ModuleCreate.KeepPartialDraft is set the draft is deleted,
+// otherwise the error can be passed as ModuleCreate.ResumeFrom to continue
+// from the failed step.
+type PartialDraftError struct {
+	DraftModuleId string
+	Completed     []DraftStep
+	Failed        DraftStep
+	Err           error
+	// Kept is true when the draft was kept for resuming, later failures of a
+	// draft resumed from this error keep it too
+	Kept bool
+	// RolledBack is true when the draft was deleted and its idempotency key
+	// forgotten
+	RolledBack bool
+	// RollbackErr is the error deleting the draft, the draft is left behind
+	RollbackErr error
+	// ForgetErr is the error forgetting the idempotency key of the deleted
+	// draft, a retry with the same key finds no draft
+	ForgetErr error
+}
+
+func (e *PartialDraftError) Error() string {
+	switch {
+	case e.RolledBack:
+		return fmt.Sprintf("%s step of draft module %s failed, the draft was deleted: %s", e.Failed, e.DraftModuleId, e.Err)
+	case e.RollbackErr != nil:
+		return fmt.Sprintf("%s step of draft module %s failed: %s, and deleting the draft failed: %s", e.Failed, e.DraftModuleId, e.Err, e.RollbackErr)
+	case e.ForgetErr != nil:
+		return fmt.Sprintf("%s step of draft module %s failed: %s, the draft was deleted but forgetting its idempotency key failed: %s", e.Failed, e.DraftModuleId, e.Err, e.ForgetErr)
+	}
+	return fmt.Sprintf("%s step of draft module %s failed: %s", e.Failed, e.DraftModuleId, e.Err)
+}
+
+// deleted returns whether the draft was deleted, even if its idempotency key
+// could not be forgotten
+func (e *PartialDraftError) deleted() bool {
+	return e.RolledBack || e.ForgetErr != nil
+}
+
+func (e *PartialDraftError) Unwrap() error {
+	return e.Err
+}
+
+// Done returns whether the step completed before the failure
+func (e *PartialDraftError) Done(step DraftStep) bool {
+	if e == nil {
+		return false
+	}
+	for _, completed := range e.Completed {
+		if completed == step {
+			return true
+		}
+	}
+	return false
+}
+
+// draftProgress tracks the completed steps of a draft so a failure can be
+// rolled back or resumed
+type draftProgress struct {
+	moduleId  string
+	completed []DraftStep
+	resumed   *PartialDraftError
+}
+
+func newDraftProgress(resumeFrom *PartialDraftError) (*draftProgress, error) {
+	if resumeFrom == nil {
+		return &draftProgress{}, nil
+	}
+	if resumeFrom.deleted() {
+		return nil, fmt.Errorf("can't resume draft module %s, it was deleted", resumeFrom.DraftModuleId)
+	}
+	return &draftProgress{
+		moduleId:  resumeFrom.DraftModuleId,
+		completed: append([]DraftStep{}, resumeFrom.Completed...),
+		resumed:   resumeFrom,
+	}, nil
+}
+
+// run runs the step unless it completed before the draft was resumed
+func (p *draftProgress) run(step DraftStep, fn func() error) error {
+	if p.resumed.Done(step) {
+		return nil
+	}
+	if err := fn(); err != nil {
+		return err
+	}
+	p.completed = append(p.completed, step)
+	return nil
+}
+
+// fail deletes the draft unless params.KeepPartialDraft is set or the draft
+// was kept before it was resumed, and returns a PartialDraftError
+func (p *draftProgress) fail(ctx context.Context, self *MarketplaceClient, params ModuleCreate, step DraftStep, err error) error {
+	partial := &PartialDraftError{
+		DraftModuleId: p.moduleId,
+		Completed:     p.completed,
+		Failed:        step,
+		Err:           err,
+	}
+	if params.KeepPartialDraft || (p.resumed != nil && p.resumed.Kept) {
+		partial.Kept = true
+		return partial
+	}
+	_, partial.RollbackErr = DeleteDraftModule(ctx, self, DeleteDraftModuleVariables{
+		Input: DeleteDraftModuleInput{ModuleId: p.moduleId},
+	})
+	if partial.RollbackErr != nil {
+		return partial
+	}
+	partial.ForgetErr = forget(ctx, self.guard, IDEMPOTENCY_KIND_DRAFT_MODULE, params.IdempotencyKey)
+	partial.RolledBack = partial.ForgetErr == nil
+	return partial
+}
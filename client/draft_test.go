@@ -0,0 +1,225 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var draftParams = AppTileCreate{
+	Name:           "Tile",
+	Icon:           &ImageUpload{FileName: "icon.png", Data: []byte("icon")},
+	AppTileId:      "app-tile-id",
+	Version:        "1.0.0",
+	IdempotencyKey: "release-1",
+}
+
+func TestCreateAppTileDraftModuleRollsBack(t *testing.T) {
+	mockClient := MockClient{
+		response: &map[string]interface{}{
+			"createDraftModule":    map[string]interface{}{"id": "draft"},
+			"startUpload":          map[string]interface{}{"id": "upload", "url": "https://uploads.test/url"},
+			"deleteDraftModule":    map[string]interface{}{"moduleId": "draft"},
+			"publishDraftModuleV2": map[string]interface{}{"id": "module"},
+		},
+		operationErrors: map[string]error{SET_APP_TILE: errors.New("service unavailable")},
+	}
+	client := MarketplaceClient{client: &mockClient, guard: &MemoryIdempotencyGuard{}, httpClient: &http.Client{Transport: &imageTransport{}}}
+	_, err := client.CreateAppTileDraftModule(draftParams)
+	var partial *PartialDraftError
+	if !errors.As(err, &partial) {
+		t.Fatal("Expected a partial draft error", err)
+	}
+	if partial.DraftModuleId != "draft" || partial.Failed != DRAFT_STEP_SOURCE || !partial.RolledBack {
+		t.Fatal("Unexpected partial draft", partial)
+	}
+	if last := mockClient.operations[len(mockClient.operations)-1]; last != DELETE_DRAFT_MODULE {
+		t.Fatal("Did not delete the draft", mockClient.operations)
+	}
+	marker := IdempotencyMarker(IDEMPOTENCY_KIND_DRAFT_MODULE, draftParams.IdempotencyKey)
+	if id, _ := client.guard.Find(context.Background(), IDEMPOTENCY_KIND_DRAFT_MODULE, marker); id != nil {
+		t.Fatal("The deleted draft should be forgotten", *id)
+	}
+
+	params := draftParams
+	params.ResumeFrom = partial
+	if _, err := client.CreateAppTileDraftModule(params); err == nil {
+		t.Fatal("A deleted draft can't be resumed")
+	}
+}
+
+func TestPublishNewAppTileModuleResumes(t *testing.T) {
+	mockClient := MockClient{
+		response: &map[string]interface{}{
+			"createDraftModule":    map[string]interface{}{"id": "draft"},
+			"startUpload":          map[string]interface{}{"id": "upload", "url": "https://uploads.test/url"},
+			"deleteDraftModule":    map[string]interface{}{"moduleId": "draft"},
+			"publishDraftModuleV2": map[string]interface{}{"id": "module"},
+		},
+		operationErrors: map[string]error{PUBLISH_MODULE: errors.New("service unavailable")},
+	}
+	client := MarketplaceClient{client: &mockClient, guard: &MemoryIdempotencyGuard{}, httpClient: &http.Client{Transport: &imageTransport{}}}
+	params := draftParams
+	params.KeepPartialDraft = true
+	_, err := client.PublishNewAppTileModule(params)
+	var partial *PartialDraftError
+	if !errors.As(err, &partial) {
+		t.Fatal("Expected a partial draft error", err)
+	}
+	if partial.RolledBack || partial.Failed != DRAFT_STEP_PUBLISH || !partial.Done(DRAFT_STEP_IMAGE) {
+		t.Fatal("Unexpected partial draft", partial)
+	}
+	for _, operation := range mockClient.operations {
+		if operation == DELETE_DRAFT_MODULE {
+			t.Fatal("The draft should have been kept")
+		}
+	}
+
+	mockClient.operations = nil
+	params.KeepPartialDraft = false
+	params.ResumeFrom = partial
+	_, err = client.PublishNewAppTileModule(params)
+	if !errors.As(err, &partial) || !partial.Kept || partial.RolledBack {
+		t.Fatal("A resumed draft should be kept again", err)
+	}
+	for _, operation := range mockClient.operations {
+		if operation == DELETE_DRAFT_MODULE {
+			t.Fatal("The resumed draft should have been kept")
+		}
+	}
+
+	delete(mockClient.operationErrors, PUBLISH_MODULE)
+	mockClient.operations = nil
+	params.ResumeFrom = partial
+	id, err := client.PublishNewAppTileModule(params)
+	if err != nil {
+		t.Fatal("Unexpected error", err)
+	}
+	if *id != "module" || len(mockClient.operations) != 1 || mockClient.operations[0] != PUBLISH_MODULE {
+		t.Fatal("Should only have published the draft", mockClient.operations)
+	}
+}
+
+func TestPartialDraftErrorReportsFailedRollback(t *testing.T) {
+	mockClient := MockClient{
+		response: &map[string]interface{}{
+			"createDraftModule":    map[string]interface{}{"id": "draft"},
+			"startUpload":          map[string]interface{}{"id": "upload", "url": "https://uploads.test/url"},
+			"deleteDraftModule":    map[string]interface{}{"moduleId": "draft"},
+			"publishDraftModuleV2": map[string]interface{}{"id": "module"},
+		},
+		operationErrors: map[string]error{
+			START_IMAGE_UPLOAD:  errors.New("service unavailable"),
+			DELETE_DRAFT_MODULE: errors.New("forbidden"),
+		},
+	}
+	client := MarketplaceClient{client: &mockClient, guard: &MemoryIdempotencyGuard{}, httpClient: &http.Client{Transport: &imageTransport{}}}
+	_, err := client.CreateAppTileDraftModule(draftParams)
+	var partial *PartialDraftError
+	if !errors.As(err, &partial) || partial.RolledBack || partial.RollbackErr == nil {
+		t.Fatal("Expected a failed rollback", err)
+	}
+	if partial.Failed != DRAFT_STEP_IMAGE || !partial.Done(DRAFT_STEP_SOURCE) {
+		t.Fatal("Unexpected steps", partial)
+	}
+	if err.Error() != "image step of draft module draft failed: service unavailable, and deleting the draft failed: forbidden" {
+		t.Fatal("Unexpected message", err.Error())
+	}
+}
+
+type forgetfulGuard struct {
+	MemoryIdempotencyGuard
+}
+
+func (g *forgetfulGuard) Forget(ctx context.Context, kind string, marker string) error {
+	return errors.New("guard unavailable")
+}
+
+func TestPartialDraftErrorReportsFailedForget(t *testing.T) {
+	mockClient := MockClient{
+		response: &map[string]interface{}{
+			"createDraftModule": map[string]interface{}{"id": "draft"},
+			"deleteDraftModule": map[string]interface{}{"moduleId": "draft"},
+		},
+		operationErrors: map[string]error{SET_APP_TILE: errors.New("service unavailable")},
+	}
+	client := MarketplaceClient{client: &mockClient, guard: &forgetfulGuard{}}
+	_, err := client.CreateAppTileDraftModule(draftParams)
+	var partial *PartialDraftError
+	if !errors.As(err, &partial) || partial.RolledBack || partial.RollbackErr != nil || partial.ForgetErr == nil {
+		t.Fatal("Expected a failed forget", err)
+	}
+	if err.Error() != "source step of draft module draft failed: service unavailable, the draft was deleted but forgetting its idempotency key failed: guard unavailable" {
+		t.Fatal("Unexpected message", err.Error())
+	}
+
+	params := draftParams
+	params.ResumeFrom = partial
+	if _, err := client.CreateAppTileDraftModule(params); err == nil {
+		t.Fatal("A deleted draft can't be resumed")
+	}
+}
+
+// keyRecordingClient records the idempotency key each operation is sent with
+type keyRecordingClient struct {
+	*MockClient
+	keys []string
+}
+
+func (c *keyRecordingClient) GqlWithContext(ctx context.Context, url string, operation string, variables map[string]interface{}, options ...GqlOption) (*map[string]interface{}, error) {
+	key, _ := IdempotencyKeyFromContext(ctx)
+	c.keys = append(c.keys, key)
+	return c.MockClient.GqlWithContext(ctx, url, operation, variables, options...)
+}
+
+func TestDraftImageStepKeepsTheIdempotencyKey(t *testing.T) {
+	mockClient := MockClient{
+		response: &map[string]interface{}{
+			"createDraftModule": map[string]interface{}{"id": "draft"},
+			"startUpload":       map[string]interface{}{"id": "upload", "url": "https://uploads.test/url"},
+		},
+	}
+	recorder := &keyRecordingClient{MockClient: &mockClient}
+	client := MarketplaceClient{client: recorder, guard: &MemoryIdempotencyGuard{}, httpClient: &http.Client{Transport: &imageTransport{}}}
+	if _, err := client.CreateAppTileDraftModule(draftParams); err != nil {
+		t.Fatal("Unexpected error", err)
+	}
+	finalized := false
+	for i, operation := range mockClient.operations {
+		if operation == FINALIZE_IMAGE_UPLOAD {
+			finalized = true
+			if recorder.keys[i] != draftParams.IdempotencyKey+":image" {
+				t.Fatal("The image step should be sent with a derived key", recorder.keys)
+			}
+		}
+	}
+	if !finalized {
+		t.Fatal("Did not attach the image", mockClient.operations)
+	}
+}
+
+func TestCreateDraftModuleReadsTheImageFirst(t *testing.T) {
+	mockClient := MockClient{}
+	client := MarketplaceClient{client: &mockClient}
+	params := draftParams
+	params.Icon = nil
+	params.Image = filepath.Join(t.TempDir(), "missing.png")
+	if _, err := client.CreateAppTileDraftModule(params); !errors.Is(err, os.ErrNotExist) {
+		t.Fatal("Expected the missing image to fail", err)
+	}
+	if mockClient.hasBeenCalled {
+		t.Fatal("The image should be read before the draft is created", mockClient.operations)
+	}
+
+	params.Image = filepath.Join(t.TempDir(), "icon.png")
+	if err := os.WriteFile(params.Image, pngImage(t, 64, 32), 0600); err != nil {
+		t.Fatal(err)
+	}
+	client.validateImages = true
+	if _, err := client.CreateAppTileDraftModule(params); !errors.Is(err, ErrInvalidImage) || mockClient.hasBeenCalled {
+		t.Fatal("The image should be checked before the draft is created", err)
+	}
+}
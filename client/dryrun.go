@@ -0,0 +1,187 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/parser"
+)
+
+// Synthetic upload urls start with this prefix
+const DRY_RUN_URL_PREFIX = "dry-run://"
+
+// PlannedRequest is a request a dry run client would have sent
+type PlannedRequest struct {
+	FunctionName string
+	Path         string
+	Method       string
+	Operation    string
+	Query        string
+	Variables    map[string]interface{}
+	// Body is set for non GraphQL requests made through Do
+	Body string
+}
+
+// DryRun collects the mutations a client would have sent. Queries are still
+// sent so reads behave as usual, mutations and non-GET Do requests are
+// recorded and answered with synthetic results instead.
+//
+// Synthetic results are derived from the selection set: `id` fields and
+// fields ending in `Id` get a generated id, `url` fields a DRY_RUN_URL_PREFIX
+// url, selected scalars at the root are `true` and everything else is null.
+type DryRun struct {
+	mutex    sync.Mutex
+	requests []PlannedRequest
+	ids      int
+}
+
+func (d *DryRun) record(request PlannedRequest) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	d.requests = append(d.requests, request)
+}
+
+func (d *DryRun) nextId() string {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	d.ids++
+	return fmt.Sprintf("dry-run-%d", d.ids)
+}
+
+// Plan returns the recorded requests in the order they were made
+func (d *DryRun) Plan() []PlannedRequest {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	plan := make([]PlannedRequest, len(d.requests))
+	copy(plan, d.requests)
+	return plan
+}
+
+// WritePlan writes a human readable description of the recorded requests
+func (d *DryRun) WritePlan(w io.Writer) error {
+	plan := d.Plan()
+	if len(plan) == 0 {
+		_, err := fmt.Fprintln(w, "No changes would be made")
+		return err
+	}
+	for i, request := range plan {
+		description := request.Operation
+		if description == "" {
+			description = request.Method
+		}
+		_, err := fmt.Fprintf(w, "%d. %s %s%s\n", i+1, description, request.FunctionName, request.Path)
+		if err != nil {
+			return err
+		}
+		details := request.Body
+		if request.Variables != nil {
+			encoded, err := json.MarshalIndent(request.Variables, "   ", "  ")
+			if err != nil {
+				return err
+			}
+			details = string(encoded)
+		}
+		if details != "" {
+			if _, err := fmt.Fprintf(w, "   %s\n", details); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// syntheticData builds a response for the named mutation in the document, or
+// the first one when no name is given
+func (d *DryRun) syntheticData(query string, operationName string) map[string]interface{} {
+	document, err := parser.ParseQuery(&ast.Source{Input: query})
+	if err != nil {
+		return map[string]interface{}{}
+	}
+	for _, operation := range document.Operations {
+		if operation.Operation == ast.Mutation && (operationName == "" || operation.Name == operationName) {
+			return d.syntheticSelection(operation.SelectionSet, document.Fragments, true)
+		}
+	}
+	return map[string]interface{}{}
+}
+
+func (d *DryRun) syntheticSelection(selections ast.SelectionSet, fragments ast.FragmentDefinitionList, root bool) map[string]interface{} {
+	data := map[string]interface{}{}
+	for _, selection := range selections {
+		switch selection := selection.(type) {
+		case *ast.Field:
+			switch {
+			case len(selection.SelectionSet) > 0:
+				data[selection.Alias] = d.syntheticSelection(selection.SelectionSet, fragments, false)
+			case root:
+				data[selection.Alias] = true
+			case selection.Name == "id" || strings.HasSuffix(selection.Name, "Id"):
+				data[selection.Alias] = d.nextId()
+			case selection.Name == "url":
+				data[selection.Alias] = DRY_RUN_URL_PREFIX + selection.Alias
+			default:
+				data[selection.Alias] = nil
+			}
+		case *ast.InlineFragment:
+			for k, v := range d.syntheticSelection(selection.SelectionSet, fragments, root) {
+				data[k] = v
+			}
+		case *ast.FragmentSpread:
+			if fragment := fragments.ForName(selection.Name); fragment != nil {
+				for k, v := range d.syntheticSelection(fragment.SelectionSet, fragments, root) {
+					data[k] = v
+				}
+			}
+		}
+	}
+	return data
+}
+
+// planGql records a mutation sent through Gql and returns its synthetic data
+func (d *DryRun) planGql(request invocationRequest, query string, operationName string, variables map[string]interface{}) map[string]interface{} {
+	d.record(PlannedRequest{
+		FunctionName: request.functionName,
+		Path:         request.path,
+		Method:       http.MethodPost,
+		Operation:    request.operation,
+		Query:        query,
+		Variables:    variables,
+	})
+	return d.syntheticData(query, operationName)
+}
+
+// planDo records a request sent through Do and returns a synthetic response
+func (d *DryRun) planDo(request invocationRequest, method string, body []byte) *http.Response {
+	planned := PlannedRequest{
+		FunctionName: request.functionName,
+		Path:         request.path,
+		Method:       method,
+		Operation:    request.operation,
+		Body:         string(body),
+	}
+	responseBody := []byte("{}")
+	var graphqlRequest struct {
+		Query         string                 `json:"query"`
+		OperationName string                 `json:"operationName"`
+		Variables     map[string]interface{} `json:"variables"`
+	}
+	if err := json.Unmarshal(body, &graphqlRequest); err == nil && graphqlRequest.Query != "" {
+		planned.Query = graphqlRequest.Query
+		planned.Variables = graphqlRequest.Variables
+		planned.Body = ""
+		responseBody, _ = json.Marshal(map[string]interface{}{"data": d.syntheticData(graphqlRequest.Query, graphqlRequest.OperationName)})
+	}
+	d.record(planned)
+	return &http.Response{
+		Body:       ioutil.NopCloser(bytes.NewBuffer(responseBody)),
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": {"application/json"}},
+	}
+}
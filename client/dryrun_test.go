@@ -0,0 +1,102 @@
+package client
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/lambda"
+)
+
+func TestDryRunPublish(t *testing.T) {
+	mock := MockInvoker{}
+	dryRun := DryRun{}
+	client := &LambdaClient{invoker: &mock}
+	WithDryRun(&dryRun)(client)
+
+	marketplace := client.Marketplace()
+	id, err := marketplace.PublishNewAppTileModule(AppTileCreate{
+		Name:      "Tile",
+		AppTileId: "app-tile-id",
+		Image:     "icon.png",
+		Version:   "1.0.0",
+	})
+	if err != nil {
+		t.Fatal("Unexpected error", err)
+	}
+	if mock.hasBeenCalled {
+		t.Fatal("Dry run should not invoke the lambda")
+	}
+	if id == nil || !strings.HasPrefix(*id, "dry-run-") {
+		t.Fatal("Expected a synthetic id", id)
+	}
+
+	plan := dryRun.Plan()
+	operations := []string{"CreateDraftModule", "SetAppTile", "StartImageUpload", "FinalizeImageUpload", "PublishModule"}
+	if len(plan) != len(operations) {
+		t.Fatal("Did not record every mutation", plan)
+	}
+	for i, operation := range operations {
+		if plan[i].Operation != operation {
+			t.Fatal("Did not record mutations in order", plan[i].Operation, operation)
+		}
+	}
+	input := plan[1].Variables["input"].(map[string]interface{})
+	if input["moduleId"] != "dry-run-1" {
+		t.Fatal("Synthetic ids should be passed to later mutations", input)
+	}
+
+	output := bytes.Buffer{}
+	if err := dryRun.WritePlan(&output); err != nil {
+		t.Fatal("Unexpected error", err)
+	}
+	if !strings.Contains(output.String(), "5. PublishModule marketplace-service:deployed") {
+		t.Fatal("Did not write the plan", output.String())
+	}
+}
+
+func TestDryRunSendsQueries(t *testing.T) {
+	mock := MockInvoker{
+		response: &lambda.InvokeOutput{
+			Payload: []byte("{ \"body\": \"{ \\\"data\\\": { \\\"result\\\": true }}\"}"),
+		},
+	}
+	dryRun := DryRun{}
+	client := &LambdaClient{invoker: &mock}
+	WithDryRun(&dryRun)(client)
+
+	_, err := client.Gql("some_lambda:status/some/path", MOCK_QUERY, map[string]interface{}{"var": "value"})
+	if err != nil {
+		t.Fatal("Unexpected error", err)
+	}
+	if !mock.hasBeenCalled || len(dryRun.Plan()) != 0 {
+		t.Fatal("Queries should still be sent")
+	}
+}
+
+func TestDryRunDo(t *testing.T) {
+	mock := MockInvoker{}
+	dryRun := DryRun{}
+	client := &LambdaClient{invoker: &mock}
+	WithDryRun(&dryRun)(client)
+
+	req := &http.Request{
+		Method: "DELETE",
+		URL:    &url.URL{Scheme: "some-service", Opaque: "deployed/v1/resource/1"},
+		Body:   ioutil.NopCloser(bytes.NewBufferString("")),
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatal("Unexpected error", err)
+	}
+	if mock.hasBeenCalled || resp.StatusCode != 200 {
+		t.Fatal("Expected a synthetic response", resp)
+	}
+	plan := dryRun.Plan()
+	if len(plan) != 1 || plan[0].Method != "DELETE" || plan[0].Path != "/v1/resource/1" {
+		t.Fatal("Did not record the request", plan)
+	}
+}
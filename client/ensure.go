@@ -0,0 +1,256 @@
+package client
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// AppTileModuleSpec is the desired state of a published app tile module, see
+// EnsureAppTileModule
+type AppTileModuleSpec struct {
+	// ModuleId is the module to update, when empty the app tile module titled
+	// Name is used and a new module is published if there is none. Finding
+	// the module by its title lists every module with ListModules, whose
+	// query is not confirmed against the deployed schema yet.
+	ModuleId    string
+	Name        string
+	Description string
+	// Image is the path of the icon, its bytes are compared with the published
+	// icon. This assumes the service serves icons as they were uploaded, an
+	// icon it re-encodes is reported as changed on every run. Without Image
+	// and Icon the module has no icon.
+	Image string
+	// Icon is used instead of the file at Image when it is set
+	Icon      *ImageUpload
+	AppTileId string
+	// Version is the version to publish, when empty the latest published
+	// version is bumped by Bump (VERSION_BUMP_PATCH by default) and new
+	// modules are published as 1.0.0
+	Version        string
+	Bump           VersionBump
+	IdempotencyKey string
+}
+
+// The fields compared by EnsureAppTileModule
+const (
+	MODULE_FIELD_TITLE       = "title"
+	MODULE_FIELD_DESCRIPTION = "description"
+	MODULE_FIELD_ICON        = "icon"
+	MODULE_FIELD_APP_TILE_ID = "appTileId"
+	MODULE_FIELD_VERSION     = "version"
+)
+
+// FieldChange is a field whose published value differs from the spec
+type FieldChange struct {
+	Field string
+	From  string
+	To    string
+}
+
+// ModuleChangeReport describes what EnsureAppTileModule found and did
+type ModuleChangeReport struct {
+	ModuleId string
+	// Created is true when no module matched the spec and a new one was
+	// published
+	Created bool
+	// Published is true when a draft was created and published
+	Published bool
+	Version   string
+	Changes   []FieldChange
+}
+
+// Changed returns whether the published module differed from the spec
+func (r *ModuleChangeReport) Changed() bool {
+	return r.Created || len(r.Changes) > 0
+}
+
+func (r *ModuleChangeReport) String() string {
+	switch {
+	case r.Created:
+		return fmt.Sprintf("created module %s at version %s", r.ModuleId, r.Version)
+	case !r.Changed():
+		return fmt.Sprintf("module %s is up to date at version %s", r.ModuleId, r.Version)
+	}
+	changes := make([]string, 0, len(r.Changes))
+	for _, change := range r.Changes {
+		changes = append(changes, fmt.Sprintf("%s: %q -> %q", change.Field, change.From, change.To))
+	}
+	action := "would publish"
+	if r.Published {
+		action = "published"
+	}
+	return fmt.Sprintf("%s module %s at version %s (%s)", action, r.ModuleId, r.Version, strings.Join(changes, ", "))
+}
+
+// PlanAppTileModule compares the published module with the spec and reports
+// the changes EnsureAppTileModule would make, without making them
+func (self *MarketplaceClient) PlanAppTileModule(spec AppTileModuleSpec) (*ModuleChangeReport, error) {
+	return self.planAppTileModule(context.Background(), spec)
+}
+
+// EnsureAppTileModule makes the published module match the spec. A new
+// version is only drafted and published when the title, description, icon,
+// app tile or version differ from the latest published version.
+func (self *MarketplaceClient) EnsureAppTileModule(spec AppTileModuleSpec) (*ModuleChangeReport, error) {
+	report, err := self.planAppTileModule(context.Background(), spec)
+	if err != nil || !report.Changed() {
+		return report, err
+	}
+	params := ModuleCreate{
+		Name:           spec.Name,
+		Description:    spec.Description,
+		Image:          spec.Image,
+		Icon:           spec.Icon,
+		Source:         &AppTileSource{Id: spec.AppTileId},
+		Version:        report.Version,
+		IdempotencyKey: spec.IdempotencyKey,
+	}
+	var id *string
+	if report.Created {
+		id, err = self.PublishNewModule(params)
+	} else {
+		id, err = self.PublishNewModuleVersion(report.ModuleId, params)
+	}
+	if err != nil {
+		return report, err
+	}
+	report.ModuleId = *id
+	report.Published = true
+	return report, nil
+}
+
+func (self *MarketplaceClient) planAppTileModule(ctx context.Context, spec AppTileModuleSpec) (*ModuleChangeReport, error) {
+	if spec.Version != "" {
+		if _, err := ParseVersion(spec.Version); err != nil {
+			return nil, err
+		}
+	}
+	moduleId, err := self.findAppTileModule(ctx, spec)
+	if err != nil {
+		return nil, err
+	}
+	if moduleId == "" {
+		version := spec.Version
+		if version == "" {
+			version = "1.0.0"
+		}
+		return &ModuleChangeReport{Created: true, Version: version}, nil
+	}
+	module, err := self.GetModule(moduleId)
+	if err != nil {
+		return nil, err
+	}
+	if module == nil {
+		return nil, fmt.Errorf("module %s does not exist", moduleId)
+	}
+	source, ok := module.Source.(*AppTileSource)
+	if !ok {
+		return nil, fmt.Errorf("module %s is not an app tile module", moduleId)
+	}
+
+	report := &ModuleChangeReport{ModuleId: module.Id, Version: module.Version}
+	compare := func(field string, published string, desired string) {
+		if published != desired {
+			report.Changes = append(report.Changes, FieldChange{Field: field, From: published, To: desired})
+		}
+	}
+	compare(MODULE_FIELD_TITLE, module.Title, spec.Name)
+	compare(MODULE_FIELD_DESCRIPTION, module.Description, spec.Description)
+	desiredIcon, err := specIcon(spec)
+	if err != nil {
+		return nil, err
+	}
+	publishedIcon, err := publishedIconDigest(ctx, self.imageHttpClient(), module.IconV2)
+	if err != nil {
+		return nil, err
+	}
+	compare(MODULE_FIELD_ICON, publishedIcon, imageDigest(desiredIcon))
+	compare(MODULE_FIELD_APP_TILE_ID, source.Id, spec.AppTileId)
+
+	switch {
+	case spec.Version != "" && spec.Version != module.Version:
+		compare(MODULE_FIELD_VERSION, module.Version, spec.Version)
+		report.Version = spec.Version
+	case len(report.Changes) == 0:
+		return report, nil
+	case spec.Version != "":
+		return nil, fmt.Errorf("module %s changed but version %s is already published: %w", moduleId, spec.Version, ErrVersionExists)
+	default:
+		bump := spec.Bump
+		if bump == "" {
+			bump = VERSION_BUMP_PATCH
+		}
+		next, err := self.NextModuleVersion(moduleId, bump)
+		if err != nil {
+			return nil, err
+		}
+		compare(MODULE_FIELD_VERSION, module.Version, next.String())
+		report.Version = next.String()
+	}
+	return report, nil
+}
+
+// findAppTileModule returns spec.ModuleId or the id of the app tile module
+// titled spec.Name, or an empty id if there is none
+func (self *MarketplaceClient) findAppTileModule(ctx context.Context, spec AppTileModuleSpec) (string, error) {
+	if spec.ModuleId != "" {
+		return spec.ModuleId, nil
+	}
+	var matches []string
+	err := self.ListModules().ForEach(ctx, func(module ModuleSummary) error {
+		if module.Category == ModuleCategoryAppTile && module.Title == spec.Name {
+			matches = append(matches, module.Id)
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	switch len(matches) {
+	case 0:
+		return "", nil
+	case 1:
+		return matches[0], nil
+	}
+	return "", fmt.Errorf("%d app tile modules are titled %q, set the ModuleId of the spec", len(matches), spec.Name)
+}
+
+// specIcon returns the icon of the spec, or nil when it has none
+func specIcon(spec AppTileModuleSpec) ([]byte, error) {
+	switch {
+	case spec.Icon != nil:
+		return spec.Icon.Data, nil
+	case spec.Image != "":
+		icon, err := ImageFromFile(spec.Image)
+		if err != nil {
+			return nil, err
+		}
+		return icon.Data, nil
+	}
+	return nil, nil
+}
+
+// publishedIconDigest downloads the published icon and returns the digest of
+// its bytes as served, or an empty digest when the module has no icon
+func publishedIconDigest(ctx context.Context, httpClient *http.Client, icon *ModuleImage) (string, error) {
+	if icon == nil {
+		return "", nil
+	}
+	data, err := downloadImage(ctx, httpClient, icon.Url)
+	if err != nil {
+		return "", fmt.Errorf("failed to download the icon: %w", err)
+	}
+	return imageDigest(data), nil
+}
+
+// imageDigest identifies an image by its content, nil images have an empty
+// digest
+func imageDigest(data []byte) string {
+	if data == nil {
+		return ""
+	}
+	return fmt.Sprintf("sha256:%x", sha256.Sum256(data))
+}
@@ -0,0 +1,261 @@
+package client
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/png"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// tileSpec returns the spec of the module, with the icon written to a file
+// when it is not nil
+func tileSpec(t *testing.T, icon []byte) AppTileModuleSpec {
+	spec := AppTileModuleSpec{
+		ModuleId:    "module-id",
+		Name:        "Tile",
+		Description: "description",
+		AppTileId:   "app-tile-id",
+	}
+	if icon != nil {
+		spec.Image = filepath.Join(t.TempDir(), "icon.png")
+		if err := os.WriteFile(spec.Image, icon, 0600); err != nil {
+			t.Fatal("Could not write the icon", err)
+		}
+	}
+	return spec
+}
+
+func TestEnsureAppTileModuleUpToDate(t *testing.T) {
+	icon := pngImage(t, 64, 64)
+	mockClient := MockClient{
+		response: &map[string]interface{}{
+			"myModule": map[string]interface{}{
+				"id":          "module-id",
+				"title":       "Tile",
+				"description": "description",
+				"version":     "1.0.0",
+				"category":    "APP_TILE",
+				"source":      map[string]interface{}{"__typename": "AppTile", "id": "app-tile-id"},
+				"versions":    []interface{}{map[string]interface{}{"version": "1.0.0"}},
+				"iconV2":      map[string]interface{}{"url": "https://images.test/icon.png", "fileName": "icon", "fileExtension": "png"},
+			},
+		},
+	}
+	client := MarketplaceClient{client: &mockClient, httpClient: &http.Client{Transport: &imageTransport{image: icon}}}
+	report, err := client.EnsureAppTileModule(tileSpec(t, icon))
+	if err != nil {
+		t.Fatal("Unexpected error", err)
+	}
+	if report.Changed() || report.Published || report.Version != "1.0.0" {
+		t.Fatal("Module should be up to date", report)
+	}
+	if len(mockClient.operations) != 1 {
+		t.Fatal("Should only have read the module", mockClient.operations)
+	}
+}
+
+func TestEnsureAppTileModulePublishesChanges(t *testing.T) {
+	icon := pngImage(t, 64, 64)
+	mockClient := MockClient{
+		response: &map[string]interface{}{
+			"myModule": map[string]interface{}{
+				"id":          "module-id",
+				"title":       "Tile",
+				"description": "old description",
+				"version":     "1.0.0",
+				"category":    "APP_TILE",
+				"source":      map[string]interface{}{"__typename": "AppTile", "id": "app-tile-id"},
+				"versions":    []interface{}{map[string]interface{}{"version": "1.0.0"}},
+				"iconV2":      map[string]interface{}{"url": "https://images.test/icon.png", "fileName": "icon", "fileExtension": "png"},
+			},
+			"createDraftModule":    map[string]interface{}{"id": "draft-id"},
+			"startUpload":          map[string]interface{}{"id": "upload", "url": "https://uploads.test/url"},
+			"publishDraftModuleV2": map[string]interface{}{"id": "module-id"},
+		},
+	}
+	client := MarketplaceClient{client: &mockClient, httpClient: &http.Client{Transport: &imageTransport{image: icon}}}
+	report, err := client.EnsureAppTileModule(tileSpec(t, icon))
+	if err != nil {
+		t.Fatal("Unexpected error", err)
+	}
+	expected := []FieldChange{
+		{Field: MODULE_FIELD_DESCRIPTION, From: "old description", To: "description"},
+		{Field: MODULE_FIELD_VERSION, From: "1.0.0", To: "1.0.1"},
+	}
+	if len(report.Changes) != len(expected) || report.Changes[0] != expected[0] || report.Changes[1] != expected[1] {
+		t.Fatal("Unexpected changes", report.Changes)
+	}
+	if !report.Published || report.ModuleId != "module-id" || report.Version != "1.0.1" {
+		t.Fatal("Should have published the new version", report)
+	}
+	last := len(mockClient.operations) - 1
+	if mockClient.operations[last] != PUBLISH_MODULE {
+		t.Fatal("Did not publish", mockClient.operations)
+	}
+	input := mockClient.variables[last]["input"].(map[string]interface{})
+	if input["version"].(map[string]interface{})["version"] != "1.0.1" {
+		t.Fatal("Published the wrong version", input)
+	}
+}
+
+func TestEnsureAppTileModuleRequiresNewVersion(t *testing.T) {
+	icon := pngImage(t, 64, 64)
+	mockClient := MockClient{
+		response: &map[string]interface{}{
+			"myModule": map[string]interface{}{
+				"id":          "module-id",
+				"title":       "Tile",
+				"description": "old description",
+				"version":     "1.0.0",
+				"category":    "APP_TILE",
+				"source":      map[string]interface{}{"__typename": "AppTile", "id": "app-tile-id"},
+				"versions":    []interface{}{map[string]interface{}{"version": "1.0.0"}},
+				"iconV2":      map[string]interface{}{"url": "https://images.test/icon.png", "fileName": "icon", "fileExtension": "png"},
+			},
+		},
+	}
+	client := MarketplaceClient{client: &mockClient, httpClient: &http.Client{Transport: &imageTransport{image: icon}}}
+	spec := tileSpec(t, icon)
+	spec.Version = "1.0.0"
+	_, err := client.EnsureAppTileModule(spec)
+	if !errors.Is(err, ErrVersionExists) {
+		t.Fatal("Expected ErrVersionExists", err)
+	}
+	for _, operation := range mockClient.operations {
+		if operation == CREATE_DRAFT_MODULE {
+			t.Fatal("Should not have created a draft")
+		}
+	}
+}
+
+func TestEnsureAppTileModuleCreates(t *testing.T) {
+	mockClient := MockClient{
+		response: &map[string]interface{}{
+			"myModules": map[string]interface{}{
+				"edges":    []interface{}{},
+				"pageInfo": map[string]interface{}{"hasNextPage": false},
+			},
+			"createDraftModule":    map[string]interface{}{"id": "draft-id"},
+			"startUpload":          map[string]interface{}{"id": "upload", "url": "https://uploads.test/url"},
+			"publishDraftModuleV2": map[string]interface{}{"id": "module-id"},
+		},
+	}
+	client := MarketplaceClient{client: &mockClient, httpClient: &http.Client{Transport: &imageTransport{}}}
+	spec := tileSpec(t, pngImage(t, 64, 64))
+	spec.ModuleId = ""
+	plan, err := client.PlanAppTileModule(spec)
+	if err != nil {
+		t.Fatal("Unexpected error", err)
+	}
+	if !plan.Created || plan.Published || len(mockClient.operations) != 1 {
+		t.Fatal("Plan should not publish", plan, mockClient.operations)
+	}
+
+	report, err := client.EnsureAppTileModule(spec)
+	if err != nil {
+		t.Fatal("Unexpected error", err)
+	}
+	if !report.Created || !report.Published || report.Version != "1.0.0" || report.ModuleId != "module-id" {
+		t.Fatal("Should have published a new module", report)
+	}
+	if report.String() != "created module module-id at version 1.0.0" {
+		t.Fatal("Unexpected summary", report.String())
+	}
+}
+
+func TestEnsureAppTileModuleComparesIconContent(t *testing.T) {
+	published := pngImage(t, 64, 64)
+	mockClient := MockClient{
+		response: &map[string]interface{}{
+			"myModule": map[string]interface{}{
+				"id":          "module-id",
+				"title":       "Tile",
+				"description": "description",
+				"version":     "1.0.0",
+				"category":    "APP_TILE",
+				"source":      map[string]interface{}{"__typename": "AppTile", "id": "app-tile-id"},
+				"versions":    []interface{}{map[string]interface{}{"version": "1.0.0"}},
+				"iconV2":      map[string]interface{}{"url": "https://images.test/icon.png", "fileName": "icon", "fileExtension": "png"},
+			},
+		},
+	}
+	client := MarketplaceClient{client: &mockClient, httpClient: &http.Client{Transport: &imageTransport{image: published}}}
+	spec := tileSpec(t, pngImage(t, 128, 128))
+	plan, err := client.PlanAppTileModule(spec)
+	if err != nil {
+		t.Fatal("Unexpected error", err)
+	}
+	if len(plan.Changes) != 2 || plan.Changes[0].Field != MODULE_FIELD_ICON || plan.Changes[0].From != imageDigest(published) {
+		t.Fatal("Expected the icon to change", plan.Changes)
+	}
+
+	spec.Image = ""
+	spec.Icon = &ImageUpload{FileName: "renamed.png", Data: published}
+	plan, err = client.PlanAppTileModule(spec)
+	if err != nil {
+		t.Fatal("Unexpected error", err)
+	}
+	if plan.Changed() {
+		t.Fatal("The same icon under another name should not change", plan.Changes)
+	}
+}
+
+func TestEnsureAppTileModuleWithoutIcon(t *testing.T) {
+	mockClient := MockClient{
+		response: &map[string]interface{}{
+			"myModule": map[string]interface{}{
+				"id":          "module-id",
+				"title":       "Tile",
+				"description": "description",
+				"version":     "1.0.0",
+				"category":    "APP_TILE",
+				"source":      map[string]interface{}{"__typename": "AppTile", "id": "app-tile-id"},
+				"versions":    []interface{}{map[string]interface{}{"version": "1.0.0"}},
+			},
+		},
+	}
+	client := MarketplaceClient{client: &mockClient, httpClient: &http.Client{Transport: &imageTransport{image: nil}}}
+	report, err := client.EnsureAppTileModule(tileSpec(t, nil))
+	if err != nil {
+		t.Fatal("Unexpected error", err)
+	}
+	if report.Changed() || len(mockClient.operations) != 1 {
+		t.Fatal("A module without an icon should be up to date", report, mockClient.operations)
+	}
+}
+
+func TestEnsureAppTileModuleComparesIconBytes(t *testing.T) {
+	// The same pixels encoded differently, as if the service re-encoded the
+	// uploaded icon
+	reencoded := &bytes.Buffer{}
+	encoder := png.Encoder{CompressionLevel: png.NoCompression}
+	if err := encoder.Encode(reencoded, image.NewRGBA(image.Rect(0, 0, 64, 64))); err != nil {
+		t.Fatal("Could not encode image", err)
+	}
+	mockClient := MockClient{
+		response: &map[string]interface{}{
+			"myModule": map[string]interface{}{
+				"id":          "module-id",
+				"title":       "Tile",
+				"description": "description",
+				"version":     "1.0.0",
+				"category":    "APP_TILE",
+				"source":      map[string]interface{}{"__typename": "AppTile", "id": "app-tile-id"},
+				"versions":    []interface{}{map[string]interface{}{"version": "1.0.0"}},
+				"iconV2":      map[string]interface{}{"url": "https://images.test/icon.png", "fileName": "icon", "fileExtension": "png"},
+			},
+		},
+	}
+	client := MarketplaceClient{client: &mockClient, httpClient: &http.Client{Transport: &imageTransport{image: reencoded.Bytes()}}}
+	plan, err := client.PlanAppTileModule(tileSpec(t, pngImage(t, 64, 64)))
+	if err != nil {
+		t.Fatal("Unexpected error", err)
+	}
+	if len(plan.Changes) != 2 || plan.Changes[0].Field != MODULE_FIELD_ICON {
+		t.Fatal("Icons are expected to be served as they were uploaded", plan.Changes)
+	}
+}
@@ -0,0 +1,4 @@
+package client
+
+//go:generate go run ../cmd/phc-codegen --schema ../schema/marketplace.graphql --package client --scalar JSON=map[string]string --out marketplace_gen.go operations/marketplace.graphql
+//go:generate go run ../cmd/phc-codegen --schema ../schema/app-store.graphql --package client --out appStore_gen.go operations/app-store.graphql
@@ -1,5 +1,9 @@
 package client
 
+import "context"
+
 type graphqlClient interface {
 	Gql(string, string, map[string]interface{}) (*map[string]interface{}, error)
+	GqlWithContext(context.Context, string, string, map[string]interface{}, ...GqlOption) (*map[string]interface{}, error)
+	GqlWithResponse(context.Context, string, string, map[string]interface{}, ...GqlOption) (*GqlResponse, error)
 }
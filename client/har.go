@@ -0,0 +1,375 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"sort"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/aws/aws-sdk-go-v2/service/lambda"
+)
+
+type harNameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type harPostData struct {
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+	// Encoding is "base64" for binary bodies, like the response content
+	Encoding string `json:"encoding,omitempty"`
+}
+
+type harRequest struct {
+	Method      string         `json:"method"`
+	Url         string         `json:"url"`
+	HttpVersion string         `json:"httpVersion"`
+	Cookies     []harNameValue `json:"cookies"`
+	Headers     []harNameValue `json:"headers"`
+	QueryString []harNameValue `json:"queryString"`
+	PostData    *harPostData   `json:"postData,omitempty"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int            `json:"bodySize"`
+}
+
+type harContent struct {
+	Size     int    `json:"size"`
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+}
+
+type harResponse struct {
+	Status      int            `json:"status"`
+	StatusText  string         `json:"statusText"`
+	HttpVersion string         `json:"httpVersion"`
+	Cookies     []harNameValue `json:"cookies"`
+	Headers     []harNameValue `json:"headers"`
+	Content     harContent     `json:"content"`
+	RedirectURL string         `json:"redirectURL"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int            `json:"bodySize"`
+}
+
+type harTimings struct {
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+}
+
+type harEntry struct {
+	StartedDateTime string      `json:"startedDateTime"`
+	Time            float64     `json:"time"`
+	Request         harRequest  `json:"request"`
+	Response        harResponse `json:"response"`
+	Cache           struct{}    `json:"cache"`
+	Timings         harTimings  `json:"timings"`
+	// Custom fields must start with an underscore in HAR 1.2
+	FunctionName  string `json:"_functionName"`
+	FunctionError string `json:"_functionError,omitempty"`
+	Error         string `json:"_error,omitempty"`
+}
+
+type harCreator struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+type harLog struct {
+	Version string     `json:"version"`
+	Creator harCreator `json:"creator"`
+	Entries []harEntry `json:"entries"`
+}
+
+// HarRecorder is an Invoker that records every invocation as an HTTP Archive
+// (HAR 1.2) entry, so the traffic can be inspected in browser devtools or any
+// HAR viewer. Install it with WrapInvoker and write it out with WriteFile.
+//
+// Request headers and GraphQL variables are redacted before they are recorded.
+// Without a Redactor the DEFAULT_REDACTED_HEADERS and every variable are
+// masked, RecordUnredacted records the requests as sent instead. The operations
+// part of multipart uploads is redacted the same way, bodies that can't be
+// parsed are left out.
+type HarRecorder struct {
+	invoker          Invoker
+	Redactor         *Redactor
+	RecordUnredacted bool
+
+	mutex   sync.Mutex
+	entries []harEntry
+}
+
+// defaultHarRedactor masks every variable, the default headers are always
+// masked by a Redactor
+var defaultHarRedactor = Redactor{VariablePaths: []string{"*"}}
+
+func NewHarRecorder(invoker Invoker) *HarRecorder {
+	return &HarRecorder{invoker: invoker}
+}
+
+func (r *HarRecorder) Invoke(ctx context.Context, input *lambda.InvokeInput, options ...func(*lambda.Options)) (*lambda.InvokeOutput, error) {
+	start := time.Now()
+	output, err := r.invoker.Invoke(ctx, input, options...)
+	elapsed := float64(time.Since(start).Microseconds()) / 1000
+
+	entry := harEntry{
+		StartedDateTime: start.UTC().Format("2006-01-02T15:04:05.000Z"),
+		Time:            elapsed,
+		Request:         r.harRequest(input),
+		Timings:         harTimings{Wait: elapsed},
+	}
+	if input.FunctionName != nil {
+		entry.FunctionName = *input.FunctionName
+	}
+	if err != nil {
+		entry.Error = err.Error()
+		entry.Response = harResponse{Cookies: []harNameValue{}, Headers: []harNameValue{}, HeadersSize: -1, BodySize: -1}
+	} else {
+		entry.Response = harResponseFromOutput(output)
+		if output.FunctionError != nil {
+			entry.FunctionError = *output.FunctionError
+		}
+	}
+
+	r.mutex.Lock()
+	r.entries = append(r.entries, entry)
+	r.mutex.Unlock()
+	return output, err
+}
+
+func (r *HarRecorder) harRequest(input *lambda.InvokeInput) harRequest {
+	request := harRequest{
+		Method:      http.MethodPost,
+		HttpVersion: "HTTP/1.1",
+		Cookies:     []harNameValue{},
+		Headers:     []harNameValue{},
+		QueryString: []harNameValue{},
+		HeadersSize: -1,
+		BodySize:    len(input.Payload),
+	}
+	functionName := ""
+	if input.FunctionName != nil {
+		functionName = *input.FunctionName
+	}
+
+	var sent payload
+	if err := json.Unmarshal(input.Payload, &sent); err != nil {
+		// Not a proxy event, record the raw payload against the function
+		request.Url = fmt.Sprintf("lambda://%s/", url.QueryEscape(functionName))
+		request.PostData = &harPostData{MimeType: "application/json", Text: string(input.Payload)}
+		return request
+	}
+
+	headers := sent.Headers
+	redactor := r.Redactor
+	if redactor == nil {
+		redactor = &defaultHarRedactor
+	}
+	if !r.RecordUnredacted {
+		headers = redactor.RedactHeaders(headers)
+	}
+
+	query := url.Values{}
+	for k, v := range sent.QueryStringParameters {
+		query.Set(k, v)
+		request.QueryString = append(request.QueryString, harNameValue{Name: k, Value: v})
+	}
+	request.Url = fmt.Sprintf("lambda://%s%s", url.QueryEscape(functionName), sent.Path)
+	if len(query) > 0 {
+		request.Url += "?" + query.Encode()
+	}
+	request.Method = sent.HttpMethod
+	request.Headers = sortedNameValues(headers)
+	request.BodySize = len(sent.Body)
+	if sent.Body != "" {
+		request.PostData = &harPostData{MimeType: headerValue(headers, "content-type")}
+		if r.RecordUnredacted {
+			request.PostData.Text = sent.Body
+			if sent.IsBase64Encoded {
+				request.PostData.Encoding = "base64"
+			}
+		} else {
+			redactPostData(redactor, sent, request.PostData)
+		}
+	}
+	return request
+}
+
+func harResponseFromOutput(output *lambda.InvokeOutput) harResponse {
+	response := harResponse{
+		Status:      int(output.StatusCode),
+		HttpVersion: "HTTP/1.1",
+		Cookies:     []harNameValue{},
+		Headers:     []harNameValue{},
+		HeadersSize: -1,
+		BodySize:    len(output.Payload),
+		Content:     harContent{Size: len(output.Payload), MimeType: "application/json", Text: string(output.Payload)},
+	}
+	var received responsePayload
+	if err := json.Unmarshal(output.Payload, &received); err == nil && received.StatusCode != 0 {
+		response.Status = received.StatusCode
+		response.Headers = sortedNameValues(received.Headers)
+		response.BodySize = len(received.Body)
+		response.Content = harContent{
+			Size:     len(received.Body),
+			MimeType: headerValue(received.Headers, "content-type"),
+			Text:     received.Body,
+		}
+	}
+	response.StatusText = http.StatusText(response.Status)
+	return response
+}
+
+// redactPostData records the body with its GraphQL variables redacted. Bodies
+// that can't be parsed are left out rather than recorded unredacted
+func redactPostData(redactor *Redactor, sent payload, postData *harPostData) {
+	body := []byte(sent.Body)
+	if sent.IsBase64Encoded {
+		decoded, err := base64.StdEncoding.DecodeString(sent.Body)
+		if err != nil {
+			return
+		}
+		body = decoded
+	}
+
+	var redacted []byte
+	var err error
+	mediaType, params, _ := mime.ParseMediaType(postData.MimeType)
+	if mediaType == "multipart/form-data" {
+		redacted, err = redactMultipartBody(redactor, body, params["boundary"])
+	} else {
+		redacted, err = redactBody(redactor, body)
+	}
+	if err != nil {
+		return
+	}
+	if utf8.Valid(redacted) {
+		postData.Text = string(redacted)
+	} else {
+		postData.Text = base64.StdEncoding.EncodeToString(redacted)
+		postData.Encoding = "base64"
+	}
+}
+
+func redactBody(redactor *Redactor, body []byte) ([]byte, error) {
+	var decoded map[string]interface{}
+	if err := json.Unmarshal(body, &decoded); err != nil {
+		return nil, err
+	}
+	variables, ok := decoded["variables"].(map[string]interface{})
+	if !ok {
+		return body, nil
+	}
+	decoded["variables"] = redactor.RedactVariables(variables)
+	return json.Marshal(decoded)
+}
+
+// redactMultipartBody redacts the operations part of a GraphQL multipart
+// request, the map and file parts are kept as sent
+func redactMultipartBody(redactor *Redactor, body []byte, boundary string) ([]byte, error) {
+	if boundary == "" {
+		return nil, errors.New("multipart body without a boundary")
+	}
+	reader := multipart.NewReader(bytes.NewReader(body), boundary)
+	redacted := &bytes.Buffer{}
+	writer := multipart.NewWriter(redacted)
+	if err := writer.SetBoundary(boundary); err != nil {
+		return nil, err
+	}
+	hasOperations := false
+	for {
+		part, err := reader.NextRawPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		content, err := ioutil.ReadAll(part)
+		if err != nil {
+			return nil, err
+		}
+		if part.FormName() == "operations" {
+			hasOperations = true
+			if content, err = redactBody(redactor, content); err != nil {
+				return nil, err
+			}
+		}
+		w, err := writer.CreatePart(part.Header)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := w.Write(content); err != nil {
+			return nil, err
+		}
+	}
+	if !hasOperations {
+		return nil, errors.New("multipart body without an operations part")
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+	return redacted.Bytes(), nil
+}
+
+func headerValue(headers map[string]string, name string) string {
+	for k, v := range headers {
+		if http.CanonicalHeaderKey(k) == http.CanonicalHeaderKey(name) {
+			return v
+		}
+	}
+	return ""
+}
+
+func sortedNameValues(values map[string]string) []harNameValue {
+	result := make([]harNameValue, 0, len(values))
+	for k, v := range values {
+		result = append(result, harNameValue{Name: k, Value: v})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Name < result[j].Name
+	})
+	return result
+}
+
+// WriteTo writes the recorded entries as a HAR document
+func (r *HarRecorder) WriteTo(w io.Writer) (int64, error) {
+	r.mutex.Lock()
+	entries := make([]harEntry, len(r.entries))
+	copy(entries, r.entries)
+	r.mutex.Unlock()
+
+	encoded, err := json.MarshalIndent(map[string]harLog{
+		"log": {
+			Version: "1.2",
+			Creator: harCreator{Name: "phc-sdk-go", Version: "1.0"},
+			Entries: entries,
+		},
+	}, "", "  ")
+	if err != nil {
+		return 0, err
+	}
+	n, err := w.Write(encoded)
+	return int64(n), err
+}
+
+// WriteFile writes the recorded entries as a HAR document to the given path
+func (r *HarRecorder) WriteFile(path string) error {
+	buffer := &bytes.Buffer{}
+	if _, err := r.WriteTo(buffer); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, buffer.Bytes(), 0600)
+}
@@ -0,0 +1,201 @@
+package client
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"path/filepath"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/lambda"
+)
+
+func TestHarRecorder(t *testing.T) {
+	mock := MockInvoker{
+		response: &lambda.InvokeOutput{
+			StatusCode: 200,
+			Payload:    []byte("{ \"body\": \"{ \\\"data\\\": { \\\"result\\\": true }}\", \"statusCode\": 200, \"headers\": { \"content-type\": \"application/json\" } }"),
+		},
+	}
+	var recorder *HarRecorder
+	client := LambdaClient{invoker: &mock, user: "test-user"}
+	WrapInvoker(func(invoker Invoker) Invoker {
+		recorder = NewHarRecorder(invoker)
+		recorder.Redactor = &Redactor{VariablePaths: []string{"var"}}
+		return recorder
+	})(&client)
+
+	_, err := client.Gql("some_lambda:status/some/path", MOCK_MUTATION, map[string]interface{}{"var": "value"})
+	if err != nil {
+		t.Fatal("Unexpected test Error", err)
+	}
+
+	file := filepath.Join(t.TempDir(), "trace.har")
+	if err := recorder.WriteFile(file); err != nil {
+		t.Fatal("Unexpected error", err)
+	}
+	raw, err := ioutil.ReadFile(file)
+	if err != nil {
+		t.Fatal("Unexpected error", err)
+	}
+	var har struct {
+		Log struct {
+			Version string
+			Entries []struct {
+				FunctionName string `json:"_functionName"`
+				Request      struct {
+					Method   string
+					Url      string
+					Headers  []harNameValue
+					PostData harPostData
+				}
+				Response struct {
+					Status  int
+					Content harContent
+				}
+			}
+		}
+	}
+	if err := json.Unmarshal(raw, &har); err != nil {
+		t.Fatal("Could not parse HAR", string(raw))
+	}
+	if har.Log.Version != "1.2" || len(har.Log.Entries) != 1 {
+		t.Fatal("Did not record the invocation", string(raw))
+	}
+	entry := har.Log.Entries[0]
+	if entry.FunctionName != "some_lambda:status" || entry.Request.Method != "POST" {
+		t.Fatal("Did not record the request", entry)
+	}
+	if entry.Request.Url != "lambda://some_lambda%3Astatus/some/path" {
+		t.Fatal("Did not build the right url", entry.Request.Url)
+	}
+	var body struct {
+		Variables map[string]interface{}
+	}
+	if err := json.Unmarshal([]byte(entry.Request.PostData.Text), &body); err != nil || body.Variables["var"] != REDACTED {
+		t.Fatal("Did not redact the request body", entry.Request.PostData.Text)
+	}
+	for _, header := range entry.Request.Headers {
+		if header.Name == "LifeOmic-Policy" && header.Value != REDACTED {
+			t.Fatal("Did not redact the policy header", header)
+		}
+	}
+	if entry.Response.Status != 200 || entry.Response.Content.MimeType != "application/json" {
+		t.Fatal("Did not record the response", entry.Response)
+	}
+}
+
+func recordHarRequest(t *testing.T, configure func(*HarRecorder)) (map[string]interface{}, string) {
+	mock := MockInvoker{
+		response: &lambda.InvokeOutput{
+			Payload: []byte("{ \"body\": \"{ \\\"data\\\": { \\\"result\\\": true }}\", \"statusCode\": 200 }"),
+		},
+	}
+	var recorder *HarRecorder
+	client := LambdaClient{invoker: &mock, rules: map[string]bool{"readData": true}}
+	WrapInvoker(func(invoker Invoker) Invoker {
+		recorder = NewHarRecorder(invoker)
+		configure(recorder)
+		return recorder
+	})(&client)
+	_, err := client.Gql("some_lambda:status/some/path", MOCK_MUTATION, map[string]interface{}{"var": "value"})
+	if err != nil {
+		t.Fatal("Unexpected test Error", err)
+	}
+	entry := recorder.entries[0]
+	var body struct {
+		Variables map[string]interface{}
+	}
+	if err := json.Unmarshal([]byte(entry.Request.PostData.Text), &body); err != nil {
+		t.Fatal("Could not parse the request body", entry.Request.PostData.Text)
+	}
+	for _, header := range entry.Request.Headers {
+		if header.Name == "LifeOmic-Policy" {
+			return body.Variables, header.Value
+		}
+	}
+	t.Fatal("Did not record the policy header", entry.Request.Headers)
+	return nil, ""
+}
+
+func TestHarRecorderRedactsByDefault(t *testing.T) {
+	variables, policy := recordHarRequest(t, func(*HarRecorder) {})
+	if variables["var"] != REDACTED || policy != REDACTED {
+		t.Fatal("Expected variables and the policy header to be redacted by default", variables, policy)
+	}
+
+	variables, policy = recordHarRequest(t, func(recorder *HarRecorder) {
+		recorder.RecordUnredacted = true
+	})
+	if variables["var"] != "value" || policy == REDACTED {
+		t.Fatal("Expected the request to be recorded as sent", variables, policy)
+	}
+}
+
+func TestHarRecorderRedactsMultipartBodies(t *testing.T) {
+	mock := MockInvoker{
+		response: &lambda.InvokeOutput{
+			Payload: []byte(`{ "statusCode": 200, "body": "{ \"data\": { \"upload\": { \"id\": \"file\" } } }"}`),
+		},
+	}
+	var recorder *HarRecorder
+	client := LambdaClient{invoker: &mock}
+	WrapInvoker(func(invoker Invoker) Invoker {
+		recorder = NewHarRecorder(invoker)
+		return recorder
+	})(&client)
+
+	icon := &Upload{File: bytes.NewReader([]byte{0x89, 'P', 'N', 'G', 0xff}), FileName: "icon.png", ContentType: "image/png"}
+	_, err := client.Gql("some-service:deployed/graphql", UPLOAD_MUTATION, map[string]interface{}{
+		"input": map[string]interface{}{"name": "secret"},
+		"files": []interface{}{icon},
+	})
+	if err != nil {
+		t.Fatal("Unexpected error", err)
+	}
+
+	postData := recorder.entries[0].Request.PostData
+	if postData == nil || postData.Encoding != "base64" {
+		t.Fatal("Expected the binary body to be recorded as base64", postData)
+	}
+	body, err := base64.StdEncoding.DecodeString(postData.Text)
+	if err != nil {
+		t.Fatal("Could not decode the recorded body", err)
+	}
+	_, params, _ := mime.ParseMediaType(postData.MimeType)
+	reader := multipart.NewReader(bytes.NewReader(body), params["boundary"])
+	parts := map[string][]byte{}
+	for {
+		part, err := reader.NextPart()
+		if err != nil {
+			break
+		}
+		parts[part.FormName()], _ = ioutil.ReadAll(part)
+	}
+	var operations struct {
+		Variables map[string]interface{}
+	}
+	if err := json.Unmarshal(parts["operations"], &operations); err != nil || operations.Variables["input"] != REDACTED {
+		t.Fatal("Did not redact the operations part", string(parts["operations"]))
+	}
+	if !bytes.Equal(parts["0"], []byte{0x89, 'P', 'N', 'G', 0xff}) {
+		t.Fatal("Did not keep the file part", parts["0"])
+	}
+}
+
+func TestHarRecorderLeavesOutUnparsableBodies(t *testing.T) {
+	recorder := NewHarRecorder(&MockInvoker{})
+	sent, _ := json.Marshal(payload{
+		Headers:    map[string]string{"Content-Type": "multipart/form-data; boundary=missing"},
+		HttpMethod: "POST",
+		Path:       "/graphql",
+		Body:       "not multipart",
+	})
+	request := recorder.harRequest(&lambda.InvokeInput{Payload: sent})
+	if request.PostData == nil || request.PostData.Text != "" || request.BodySize == 0 {
+		t.Fatal("Expected the body to be left out", request.PostData)
+	}
+}
@@ -0,0 +1,179 @@
+package client
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// Mutations are sent with this header so services can recognize retries
+const IDEMPOTENCY_KEY_HEADER = "Idempotency-Key"
+
+// Kinds of resources guarded against duplicate creation
+const (
+	IDEMPOTENCY_KIND_APP_STORE_LISTING = "app-store-listing"
+	IDEMPOTENCY_KIND_DRAFT_MODULE      = "draft-module"
+)
+
+type idempotencyKeyContext struct{}
+
+// WithIdempotencyKey returns a context that sends key as the idempotency key
+// of mutations made with it. Retries of the same logical request must use the
+// same key.
+func WithIdempotencyKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, idempotencyKeyContext{}, key)
+}
+
+func IdempotencyKeyFromContext(ctx context.Context) (string, bool) {
+	key, ok := ctx.Value(idempotencyKeyContext{}).(string)
+	return key, ok && key != ""
+}
+
+// NewIdempotencyKey generates a random version 4 UUID
+func NewIdempotencyKey() (string, error) {
+	var uuid [16]byte
+	if _, err := rand.Read(uuid[:]); err != nil {
+		return "", err
+	}
+	uuid[6] = (uuid[6] & 0x0f) | 0x40
+	uuid[8] = (uuid[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", uuid[0:4], uuid[4:6], uuid[6:8], uuid[8:10], uuid[10:]), nil
+}
+
+// IdempotencyMarker derives a deterministic marker for a resource created
+// with the given key, so an existing resource can be found again on retry
+func IdempotencyMarker(kind string, key string) string {
+	sum := sha256.Sum256([]byte(kind + "\x00" + key))
+	return "phc-idempotency-" + hex.EncodeToString(sum[:16])
+}
+
+// ErrIdempotencyPending is returned when a create made with the same
+// idempotency key was sent but its outcome is unknown, and the resource can't
+// be looked up on the service to find out
+var ErrIdempotencyPending = errors.New("a create with the same idempotency key may already have succeeded")
+
+// IdempotencyGuard protects create mutations for services that don't
+// deduplicate idempotency keys themselves. Before creating a resource the
+// client asks the guard for an existing resource with the same marker and
+// reuses it instead of creating a duplicate.
+//
+// Markers are only kept by the guard, not on the resources, so retries made
+// after a restart are only protected by a guard that persists them.
+type IdempotencyGuard interface {
+	// Find returns the id of the resource created with the marker, or nil if
+	// there is none. ErrIdempotencyPending is returned when the marker was
+	// reserved but the create was never confirmed.
+	Find(ctx context.Context, kind string, marker string) (*string, error)
+	// Reserve records that a create with the marker is about to be sent
+	Reserve(ctx context.Context, kind string, marker string) error
+	// Remember records that the resource with id was created with the marker
+	Remember(ctx context.Context, kind string, marker string, id string) error
+	// Forget removes the marker, which is done when the create was refused or
+	// the resource was deleted so a retry with the same key creates it again
+	Forget(ctx context.Context, kind string, marker string) error
+}
+
+// MemoryIdempotencyGuard remembers created resources for the lifetime of the
+// process, which covers retries made by the same caller
+type MemoryIdempotencyGuard struct {
+	mutex sync.Mutex
+	// ids holds an empty id for reserved markers
+	ids map[string]string
+}
+
+func (g *MemoryIdempotencyGuard) Find(ctx context.Context, kind string, marker string) (*string, error) {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	id, ok := g.ids[kind+"/"+marker]
+	switch {
+	case !ok:
+		return nil, nil
+	case id == "":
+		return nil, ErrIdempotencyPending
+	}
+	return &id, nil
+}
+
+func (g *MemoryIdempotencyGuard) Reserve(ctx context.Context, kind string, marker string) error {
+	return g.Remember(ctx, kind, marker, "")
+}
+
+func (g *MemoryIdempotencyGuard) Remember(ctx context.Context, kind string, marker string, id string) error {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	if g.ids == nil {
+		g.ids = map[string]string{}
+	}
+	g.ids[kind+"/"+marker] = id
+	return nil
+}
+
+func (g *MemoryIdempotencyGuard) Forget(ctx context.Context, kind string, marker string) error {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	delete(g.ids, kind+"/"+marker)
+	return nil
+}
+
+// forget removes the resource created with key from the guard
+func forget(ctx context.Context, guard IdempotencyGuard, kind string, key string) error {
+	if key == "" || guard == nil {
+		return nil
+	}
+	return guard.Forget(ctx, kind, IdempotencyMarker(kind, key))
+}
+
+// createOnce runs create with the idempotency key attached to its context. An
+// existing resource created with the same key is returned from the guard
+// instead of calling create again.
+func createOnce(ctx context.Context, guard IdempotencyGuard, kind string, key string, create func(ctx context.Context) (*string, error)) (*string, error) {
+	if key == "" {
+		return create(ctx)
+	}
+	ctx = WithIdempotencyKey(ctx, key)
+	if guard == nil {
+		return create(ctx)
+	}
+	marker := IdempotencyMarker(kind, key)
+
+	existing, err := guard.Find(ctx, kind, marker)
+	switch {
+	case errors.Is(err, ErrIdempotencyPending):
+		return nil, fmt.Errorf("%s with idempotency key %s: %w", kind, key, ErrIdempotencyPending)
+	case err != nil:
+		return nil, err
+	case existing != nil:
+		return existing, nil
+	}
+
+	if err := guard.Reserve(ctx, kind, marker); err != nil {
+		return nil, err
+	}
+	id, err := create(ctx)
+	if err != nil {
+		// The reservation is kept when the service may have created the
+		// resource before failing, only a refusal releases it
+		var refused GqlError
+		if errors.As(err, &refused) {
+			if forgetErr := guard.Forget(ctx, kind, marker); forgetErr != nil {
+				return nil, fmt.Errorf("%w, and releasing the idempotency key failed: %s", err, forgetErr)
+			}
+		}
+		return nil, err
+	}
+	return id, guard.Remember(ctx, kind, marker, *id)
+}
+
+// deriveIdempotencyKey returns a context whose idempotency key is derived
+// from the key in ctx, for the follow up mutations of a multi-step create
+func deriveIdempotencyKey(ctx context.Context, step string) context.Context {
+	key, ok := IdempotencyKeyFromContext(ctx)
+	if !ok {
+		return ctx
+	}
+	return WithIdempotencyKey(ctx, key+":"+step)
+}
@@ -0,0 +1,157 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/lambda"
+)
+
+func sentHeaders(t *testing.T, mock *MockInvoker) map[string]string {
+	var sent payload
+	if err := json.Unmarshal(mock.payload.Payload, &sent); err != nil {
+		t.Fatal("Could not parse payload", err)
+	}
+	return sent.Headers
+}
+
+func TestGqlSendsIdempotencyKey(t *testing.T) {
+	mock := MockInvoker{
+		response: &lambda.InvokeOutput{
+			Payload: []byte("{ \"body\": \"{ \\\"data\\\": { \\\"result\\\": true }}\"}"),
+		},
+	}
+	client := LambdaClient{invoker: &mock}
+
+	ctx := WithIdempotencyKey(context.Background(), "caller-key")
+	_, err := client.GqlWithContext(ctx, "some_lambda:status/some/path", MOCK_MUTATION, nil)
+	if err != nil {
+		t.Fatal("Unexpected error", err)
+	}
+	if key := sentHeaders(t, &mock)[IDEMPOTENCY_KEY_HEADER]; key != "caller-key" {
+		t.Fatal("Did not send the caller's key", key)
+	}
+
+	_, err = client.Gql("some_lambda:status/some/path", MOCK_MUTATION, nil)
+	if err != nil {
+		t.Fatal("Unexpected error", err)
+	}
+	if key := sentHeaders(t, &mock)[IDEMPOTENCY_KEY_HEADER]; len(key) != 36 {
+		t.Fatal("Did not generate a key for the mutation", key)
+	}
+
+	_, err = client.Gql("some_lambda:status/some/path", MOCK_QUERY, nil)
+	if err != nil {
+		t.Fatal("Unexpected error", err)
+	}
+	if key, ok := sentHeaders(t, &mock)[IDEMPOTENCY_KEY_HEADER]; ok {
+		t.Fatal("Queries should not have an idempotency key", key)
+	}
+}
+
+func TestCreateAppStoreListingIsGuarded(t *testing.T) {
+	mockResponse := map[string]interface{}{
+		"createWebApp": map[string]interface{}{
+			"id": "some_app_id",
+		},
+	}
+	mockClient := MockClient{
+		response: &mockResponse,
+	}
+	client := AppStoreClient{
+		client: &mockClient,
+		guard:  &MemoryIdempotencyGuard{},
+	}
+	params := AppStoreCreate{Name: "App"}
+	ctx := WithIdempotencyKey(context.Background(), "release-1")
+
+	id, err := client.CreateAppStoreListingWithContext(ctx, params)
+	if err != nil || *id != "some_app_id" {
+		t.Fatal("Unexpected result", id, err)
+	}
+	if input := mockClient.variables[0]["input"].(map[string]interface{}); input["product"] != "LX" {
+		t.Fatal("Listings should default to the LX product", input)
+	}
+
+	mockClient.hasBeenCalled = false
+	id, err = client.CreateAppStoreListingWithContext(ctx, params)
+	if err != nil || *id != "some_app_id" {
+		t.Fatal("Unexpected result", id, err)
+	}
+	if mockClient.hasBeenCalled {
+		t.Fatal("Retry should have reused the existing listing")
+	}
+
+	_, err = client.CreateAppStoreListingWithContext(WithIdempotencyKey(context.Background(), "release-2"), params)
+	if err != nil || !mockClient.hasBeenCalled {
+		t.Fatal("A new key should create a new listing", err)
+	}
+}
+
+func TestIdempotencyMarker(t *testing.T) {
+	if IdempotencyMarker("kind", "key") != IdempotencyMarker("kind", "key") {
+		t.Fatal("Markers should be deterministic")
+	}
+	if IdempotencyMarker("kind", "key") == IdempotencyMarker("other", "key") {
+		t.Fatal("Markers should depend on the kind")
+	}
+}
+
+func TestCreateAppStoreListingKeepsAmbiguousFailuresPending(t *testing.T) {
+	mockClient := MockClient{error: errors.New("timeout")}
+	client := AppStoreClient{
+		client: &mockClient,
+		guard:  &MemoryIdempotencyGuard{},
+	}
+	params := AppStoreCreate{Name: "App"}
+	ctx := WithIdempotencyKey(context.Background(), "release-1")
+
+	if _, err := client.CreateAppStoreListingWithContext(ctx, params); err == nil {
+		t.Fatal("Expected the create to fail")
+	}
+	mockClient.hasBeenCalled = false
+	_, err := client.CreateAppStoreListingWithContext(ctx, params)
+	if !errors.Is(err, ErrIdempotencyPending) || mockClient.hasBeenCalled {
+		t.Fatal("A retry after a timeout should not create another listing", err)
+	}
+
+	mockClient.error = GqlError{Message: "invalid url"}
+	ctx = WithIdempotencyKey(context.Background(), "release-2")
+	if _, err := client.CreateAppStoreListingWithContext(ctx, params); err == nil {
+		t.Fatal("Expected the create to fail")
+	}
+	mockClient.hasBeenCalled = false
+	mockClient.error = nil
+	mockClient.response = &map[string]interface{}{"createWebApp": map[string]interface{}{"id": "some_app_id"}}
+	id, err := client.CreateAppStoreListingWithContext(ctx, params)
+	if err != nil || *id != "some_app_id" || !mockClient.hasBeenCalled {
+		t.Fatal("A refused create should be retried", id, err)
+	}
+}
+
+func TestCreateDraftModuleKeepsTheDescription(t *testing.T) {
+	mockClient := MockClient{
+		response: &map[string]interface{}{
+			"createDraftModule": map[string]interface{}{"id": "draft"},
+			"setAppTile":        map[string]interface{}{"moduleId": "draft"},
+		},
+	}
+	client := MarketplaceClient{client: &mockClient}
+	id, err := client.CreateAppTileDraftModule(AppTileCreate{
+		Name:           "Tile",
+		Description:    "description",
+		AppTileId:      "app-tile-id",
+		IdempotencyKey: "release-1",
+	})
+	if err != nil || *id != "draft" {
+		t.Fatal("Unexpected result", id, err)
+	}
+	if mockClient.operations[0] != CREATE_DRAFT_MODULE || len(mockClient.operations) != 2 {
+		t.Fatal("Drafts should only be looked up in the guard", mockClient.operations)
+	}
+	if input := mockClient.variables[0]["input"].(map[string]interface{}); input["description"] != "description" {
+		t.Fatal("The description should be sent as given", input)
+	}
+}
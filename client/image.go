@@ -0,0 +1,245 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"os"
+	"path"
+	"strings"
+)
+
+// MAX_IMAGE_UPLOAD_SIZE is the most bytes read from an image reader
+const MAX_IMAGE_UPLOAD_SIZE = 10 << 20
+
+// ErrInvalidImage is wrapped by the errors of images that don't follow the
+// marketplace rules
+var ErrInvalidImage = errors.New("invalid image")
+
+// ImageUpload is an image read into memory to be uploaded to the marketplace
+type ImageUpload struct {
+	FileName string
+	Data     []byte
+}
+
+// NewImageUpload reads the image from the reader, up to MAX_IMAGE_UPLOAD_SIZE
+// bytes. The reader is not closed.
+func NewImageUpload(fileName string, reader io.Reader) (*ImageUpload, error) {
+	data, err := io.ReadAll(io.LimitReader(reader, MAX_IMAGE_UPLOAD_SIZE+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > MAX_IMAGE_UPLOAD_SIZE {
+		return nil, fmt.Errorf("%s is larger than %d bytes: %w", fileName, MAX_IMAGE_UPLOAD_SIZE, ErrInvalidImage)
+	}
+	return &ImageUpload{FileName: fileName, Data: data}, nil
+}
+
+// ImageFromFile reads the image at the path, named after the file
+func ImageFromFile(filePath string) (*ImageUpload, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return NewImageUpload(path.Base(filePath), file)
+}
+
+// ContentType sniffs the media type of the image from its content
+func (i *ImageUpload) ContentType() string {
+	return http.DetectContentType(i.Data)
+}
+
+// ImageRules are the constraints the marketplace puts on uploaded images. A
+// zero limit is not checked.
+type ImageRules struct {
+	ContentTypes []string
+	MaxSize      int
+	MinWidth     int
+	MinHeight    int
+	MaxWidth     int
+	MaxHeight    int
+	Square       bool
+}
+
+// ICON_IMAGE_RULES are the rules for module icons
+var ICON_IMAGE_RULES = ImageRules{
+	ContentTypes: []string{"image/png", "image/jpeg"},
+	MaxSize:      5 << 20,
+	MinWidth:     32,
+	MinHeight:    32,
+	MaxWidth:     2048,
+	MaxHeight:    2048,
+	Square:       true,
+}
+
+// SCREENSHOT_IMAGE_RULES are the rules for module screenshots
+var SCREENSHOT_IMAGE_RULES = ImageRules{
+	ContentTypes: []string{"image/png", "image/jpeg"},
+	MaxSize:      8 << 20,
+	MinWidth:     320,
+	MinHeight:    320,
+	MaxWidth:     4096,
+	MaxHeight:    4096,
+}
+
+// BANNER_IMAGE_RULES are the rules for module banners
+var BANNER_IMAGE_RULES = ImageRules{
+	ContentTypes: []string{"image/png", "image/jpeg"},
+	MaxSize:      5 << 20,
+	MinWidth:     1024,
+	MinHeight:    256,
+	MaxWidth:     4096,
+	MaxHeight:    2048,
+}
+
+// ImageRulesFor returns the rules for images of the type, types without
+// rules only need to be images
+func ImageRulesFor(imageType ImageType) ImageRules {
+	switch imageType {
+	case ImageTypeIcon:
+		return ICON_IMAGE_RULES
+	case ImageTypeScreenshot:
+		return SCREENSHOT_IMAGE_RULES
+	case ImageTypeBanner:
+		return BANNER_IMAGE_RULES
+	}
+	return ImageRules{ContentTypes: []string{"image/png", "image/jpeg", "image/gif"}}
+}
+
+// ModuleImage is an image attached to a module
+type ModuleImage = ImageFields
+
+// Validate checks the content type, size and dimensions of the image
+func (i *ImageUpload) Validate(rules ImageRules) error {
+	invalid := func(format string, args ...interface{}) error {
+		return fmt.Errorf("%s: %s: %w", i.FileName, fmt.Sprintf(format, args...), ErrInvalidImage)
+	}
+	if len(i.Data) == 0 {
+		return invalid("image is empty")
+	}
+	contentType := i.ContentType()
+	if len(rules.ContentTypes) > 0 && !contains(rules.ContentTypes, contentType) {
+		return invalid("content type %s is not one of %s", contentType, strings.Join(rules.ContentTypes, ", "))
+	}
+	if rules.MaxSize > 0 && len(i.Data) > rules.MaxSize {
+		return invalid("%d bytes is larger than %d bytes", len(i.Data), rules.MaxSize)
+	}
+	config, _, err := image.DecodeConfig(bytes.NewReader(i.Data))
+	if err != nil {
+		return invalid("can't read dimensions: %s", err)
+	}
+	switch {
+	case config.Width < rules.MinWidth || config.Height < rules.MinHeight:
+		return invalid("%dx%d is smaller than %dx%d", config.Width, config.Height, rules.MinWidth, rules.MinHeight)
+	case rules.MaxWidth > 0 && config.Width > rules.MaxWidth, rules.MaxHeight > 0 && config.Height > rules.MaxHeight:
+		return invalid("%dx%d is larger than %dx%d", config.Width, config.Height, rules.MaxWidth, rules.MaxHeight)
+	case rules.Square && config.Width != config.Height:
+		return invalid("%dx%d is not square", config.Width, config.Height)
+	}
+	return nil
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// downloadImage reads the image at the url, up to MAX_IMAGE_UPLOAD_SIZE bytes
+func downloadImage(ctx context.Context, httpClient *http.Client, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("image download failed with status %d", resp.StatusCode)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, MAX_IMAGE_UPLOAD_SIZE+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > MAX_IMAGE_UPLOAD_SIZE {
+		return nil, fmt.Errorf("image is larger than %d bytes", MAX_IMAGE_UPLOAD_SIZE)
+	}
+	return data, nil
+}
+
+// S3Error is the XML error returned by S3 when an upload is refused
+type S3Error struct {
+	StatusCode int    `xml:"-"`
+	Code       string `xml:"Code"`
+	Message    string `xml:"Message"`
+	RequestId  string `xml:"RequestId"`
+}
+
+func (e *S3Error) Error() string {
+	if e.Code == "" {
+		return fmt.Sprintf("image upload failed with status %d: %s", e.StatusCode, e.Message)
+	}
+	return fmt.Sprintf("image upload failed with status %d: %s: %s", e.StatusCode, e.Code, e.Message)
+}
+
+// postImage uploads the image to a presigned POST url
+func postImage(ctx context.Context, httpClient *http.Client, url string, fields map[string]string, upload *ImageUpload) error {
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	for key, val := range fields {
+		if err := writer.WriteField(key, val); err != nil {
+			return err
+		}
+	}
+	// S3 ignores the fields after the file, so it is written last
+	header := make(textproto.MIMEHeader)
+	header.Set("Content-Disposition", fmt.Sprintf(`form-data; name="file"; filename="%s"`, escapeQuotes(upload.FileName)))
+	header.Set("Content-Type", upload.ContentType())
+	part, err := writer.CreatePart(header)
+	if err != nil {
+		return err
+	}
+	if _, err := part.Write(upload.Data); err != nil {
+		return err
+	}
+	if err := writer.Close(); err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", url, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+	responseBody, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	s3Error := &S3Error{}
+	if err := xml.Unmarshal(responseBody, s3Error); err != nil {
+		s3Error.Message = strings.TrimSpace(string(responseBody))
+	}
+	s3Error.StatusCode = resp.StatusCode
+	return s3Error
+}
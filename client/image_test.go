@@ -0,0 +1,306 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	"image/png"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func pngImage(t *testing.T, width int, height int) []byte {
+	buffer := &bytes.Buffer{}
+	if err := png.Encode(buffer, image.NewRGBA(image.Rect(0, 0, width, height))); err != nil {
+		t.Fatal("Could not encode image", err)
+	}
+	return buffer.Bytes()
+}
+
+func TestValidateImage(t *testing.T) {
+	icon, err := NewImageUpload("icon.png", bytes.NewReader(pngImage(t, 64, 64)))
+	if err != nil {
+		t.Fatal("Unexpected error", err)
+	}
+	if icon.ContentType() != "image/png" {
+		t.Fatal("Did not sniff the content type", icon.ContentType())
+	}
+	if err := icon.Validate(ICON_IMAGE_RULES); err != nil {
+		t.Fatal("Unexpected error", err)
+	}
+
+	invalid := map[string][]byte{
+		"not square": pngImage(t, 64, 32),
+		"smaller":    pngImage(t, 16, 16),
+		"not one of": []byte("<svg></svg>"),
+		"empty":      {},
+	}
+	for reason, data := range invalid {
+		err := (&ImageUpload{FileName: "icon.png", Data: data}).Validate(ICON_IMAGE_RULES)
+		if !errors.Is(err, ErrInvalidImage) || !strings.Contains(err.Error(), reason) {
+			t.Fatal("Expected an invalid image error for", reason, err)
+		}
+	}
+
+	_, err = NewImageUpload("huge.png", io.LimitReader(zeros{}, MAX_IMAGE_UPLOAD_SIZE+1))
+	if !errors.Is(err, ErrInvalidImage) {
+		t.Fatal("Expected the image to be too large", err)
+	}
+}
+
+type zeros struct{}
+
+func (zeros) Read(p []byte) (int, error) {
+	for i := range p {
+		p[i] = 0
+	}
+	return len(p), nil
+}
+
+// imageTransport serves image downloads and accepts every upload, recording
+// the urls uploaded to
+type imageTransport struct {
+	image   []byte
+	uploads []string
+}
+
+func (i *imageTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method == http.MethodGet {
+		return &http.Response{StatusCode: http.StatusOK, Header: http.Header{}, Body: io.NopCloser(bytes.NewReader(i.image)), Request: req}, nil
+	}
+	i.uploads = append(i.uploads, req.URL.String())
+	return &http.Response{StatusCode: http.StatusNoContent, Header: http.Header{}, Body: io.NopCloser(bytes.NewReader(nil)), Request: req}, nil
+}
+
+func TestImageFromFile(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "icon.png")
+	if err := os.WriteFile(filePath, pngImage(t, 32, 32), 0600); err != nil {
+		t.Fatal(err)
+	}
+	icon, err := ImageFromFile(filePath)
+	if err != nil {
+		t.Fatal("Unexpected error", err)
+	}
+	if icon.FileName != "icon.png" || icon.Validate(ICON_IMAGE_RULES) != nil {
+		t.Fatal("Did not read the file", icon.FileName)
+	}
+}
+
+func TestAttachIconToDraftModule(t *testing.T) {
+	data := pngImage(t, 64, 64)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		file, header, err := r.FormFile("file")
+		if err != nil {
+			t.Error("No file part", err)
+			return
+		}
+		received, _ := io.ReadAll(file)
+		if r.FormValue("key") != "uploads/icon.png" || header.Header.Get("Content-Type") != "image/png" || !bytes.Equal(received, data) {
+			t.Error("Unexpected upload", r.FormValue("key"), header.Header)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	mockClient := MockClient{
+		response: &map[string]interface{}{
+			"startUpload": map[string]interface{}{
+				"id":     "upload",
+				"url":    server.URL,
+				"fields": map[string]interface{}{"key": "uploads/icon.png"},
+			},
+		},
+	}
+	client := MarketplaceClient{client: &mockClient}
+	err := client.AttachIconToDraftModule("draft", &ImageUpload{FileName: "icon.png", Data: data})
+	if err != nil {
+		t.Fatal("Unexpected error", err)
+	}
+	if mockClient.operations[1] != FINALIZE_IMAGE_UPLOAD {
+		t.Fatal("Did not finalize the upload", mockClient.operations)
+	}
+
+	mockClient.operations = nil
+	client.validateImages = true
+	err = client.AttachIconToDraftModule("draft", &ImageUpload{FileName: "icon.png", Data: pngImage(t, 64, 32)})
+	if !errors.Is(err, ErrInvalidImage) || mockClient.operations != nil {
+		t.Fatal("Invalid icons should be refused before starting an upload", err)
+	}
+}
+
+func TestDryRunSkipsImageUploads(t *testing.T) {
+	mockClient := MockClient{
+		response: &map[string]interface{}{
+			"startUpload": map[string]interface{}{"id": "upload", "url": "https://uploads.test/url"},
+		},
+	}
+	images := imageTransport{}
+	client := MarketplaceClient{client: &mockClient, dryRun: true, httpClient: &http.Client{Transport: &images}}
+	err := client.AttachImageToDraftModule("draft", filepath.Join(t.TempDir(), "missing.png"))
+	if err != nil {
+		t.Fatal("Dry runs should not load the image", err)
+	}
+	if len(images.uploads) != 0 || mockClient.operations[1] != FINALIZE_IMAGE_UPLOAD {
+		t.Fatal("Dry runs should only plan the upload", images.uploads, mockClient.operations)
+	}
+}
+
+func TestPostImageSurfacesS3Errors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+		w.Write([]byte(`<?xml version="1.0" encoding="UTF-8"?>
+<Error><Code>AccessDenied</Code><Message>Policy expired</Message><RequestId>request-id</RequestId></Error>`))
+	}))
+	defer server.Close()
+
+	err := postImage(context.Background(), http.DefaultClient, server.URL, nil, &ImageUpload{FileName: "icon.png", Data: pngImage(t, 32, 32)})
+	var s3Error *S3Error
+	if !errors.As(err, &s3Error) {
+		t.Fatal("Expected an S3 error", err)
+	}
+	if s3Error.StatusCode != 403 || s3Error.Code != "AccessDenied" || s3Error.Message != "Policy expired" || s3Error.RequestId != "request-id" {
+		t.Fatal("Did not decode the error", s3Error)
+	}
+}
+
+func TestAttachDraftModuleImageUsesTypeRules(t *testing.T) {
+	mockClient := MockClient{
+		response: &map[string]interface{}{
+			"startUpload": map[string]interface{}{"id": "upload", "url": "https://uploads.test/url"},
+			"finalizeUpload": map[string]interface{}{
+				"moduleId": "draft",
+				"image":    map[string]interface{}{"id": "image-1", "type": "SCREENSHOT", "position": 2, "url": "url", "width": 640, "height": 480},
+			},
+		},
+	}
+	images := imageTransport{}
+	client := MarketplaceClient{client: &mockClient, httpClient: &http.Client{Transport: &images}}
+
+	small := &ImageUpload{FileName: "small.png", Data: pngImage(t, 64, 64)}
+	if _, err := client.AttachDraftModuleImage("draft", ImageTypeScreenshot, small); err != nil {
+		t.Fatal("Images should only be checked when validation is enabled", err)
+	}
+	if len(images.uploads) != 1 || images.uploads[0] != "https://uploads.test/url" {
+		t.Fatal("Did not upload the image", images.uploads)
+	}
+	mockClient.variables = nil
+	client.validateImages = true
+	_, err := client.AttachDraftModuleImage("draft", ImageTypeScreenshot, small)
+	if !errors.Is(err, ErrInvalidImage) {
+		t.Fatal("Screenshots should be larger than icons", err)
+	}
+
+	image, err := client.AttachDraftModuleImage("draft", ImageTypeScreenshot, &ImageUpload{FileName: "screen.png", Data: pngImage(t, 640, 480)})
+	if err != nil {
+		t.Fatal("Unexpected error", err)
+	}
+	if image.Id != "image-1" || image.Position != 2 || *image.Width != 640 {
+		t.Fatal("Did not decode the attached image", image)
+	}
+	input := mockClient.variables[1]["input"].(map[string]interface{})
+	if input["type"] != "SCREENSHOT" {
+		t.Fatal("Did not finalize as a screenshot", input)
+	}
+	if mockClient.operations[len(mockClient.operations)-1] != FINALIZE_MODULE_IMAGE_UPLOAD {
+		t.Fatal("Should select the attached image", mockClient.operations)
+	}
+}
+
+func TestReorderAndRemoveDraftModuleImages(t *testing.T) {
+	images := []interface{}{
+		map[string]interface{}{"id": "image-2", "type": "SCREENSHOT", "position": 0},
+		map[string]interface{}{"id": "image-1", "type": "SCREENSHOT", "position": 1},
+	}
+	mockClient := MockClient{
+		response: &map[string]interface{}{
+			"reorderDraftModuleImages": map[string]interface{}{"id": "draft", "images": images},
+			"removeDraftModuleImage":   map[string]interface{}{"id": "draft", "images": images[:1]},
+		},
+	}
+	client := MarketplaceClient{client: &mockClient}
+
+	reordered, err := client.ReorderDraftModuleImages("draft", ImageTypeScreenshot, []string{"image-2", "image-1"})
+	if err != nil {
+		t.Fatal("Unexpected error", err)
+	}
+	if len(reordered) != 2 || reordered[0].Id != "image-2" {
+		t.Fatal("Unexpected images", reordered)
+	}
+	input := mockClient.variables[0]["input"].(map[string]interface{})
+	if ids := input["imageIds"].([]interface{}); len(ids) != 2 || ids[0] != "image-2" {
+		t.Fatal("Did not send the order", input)
+	}
+
+	remaining, err := client.RemoveDraftModuleImage("draft", "image-1")
+	if err != nil {
+		t.Fatal("Unexpected error", err)
+	}
+	if len(remaining) != 1 || mockClient.operations[1] != REMOVE_DRAFT_MODULE_IMAGE {
+		t.Fatal("Unexpected images", remaining)
+	}
+}
+
+func TestGetModuleDecodesImages(t *testing.T) {
+	mockClient := MockClient{
+		response: &map[string]interface{}{
+			"myModule": map[string]interface{}{
+				"id":     "module",
+				"source": map[string]interface{}{"__typename": "AppTile", "id": "tile"},
+				"iconV2": map[string]interface{}{"id": "icon", "type": "ICON", "fileName": "icon", "fileExtension": "png"},
+				"images": []interface{}{
+					map[string]interface{}{"id": "banner", "type": "BANNER", "position": 0, "width": 1024, "height": 256},
+				},
+			},
+		},
+	}
+	client := MarketplaceClient{client: &mockClient}
+	module, err := client.GetModule("module")
+	if err != nil {
+		t.Fatal("Unexpected error", err)
+	}
+	if module.IconV2.Type != ImageTypeIcon || len(module.Images) != 1 || module.Images[0].Type != ImageTypeBanner || *module.Images[0].Height != 256 {
+		t.Fatal("Did not decode the images", module.IconV2, module.Images)
+	}
+	if !strings.Contains(mockClient.operations[0], "images {") {
+		t.Fatal("Did not select the images", mockClient.operations[0])
+	}
+}
+
+func TestGetDraftModuleImages(t *testing.T) {
+	mockClient := MockClient{
+		response: &map[string]interface{}{
+			"myDraftModule": map[string]interface{}{
+				"id":     "draft",
+				"iconV2": map[string]interface{}{"id": "icon", "type": "ICON"},
+				"images": []interface{}{map[string]interface{}{"id": "screen", "type": "SCREENSHOT", "position": 0}},
+			},
+		},
+	}
+	client := MarketplaceClient{client: &mockClient}
+	draft, err := client.GetDraftModuleImages("draft")
+	if err != nil {
+		t.Fatal("Unexpected error", err)
+	}
+	if draft.IconV2.Id != "icon" || len(draft.Images) != 1 || draft.Images[0].Id != "screen" {
+		t.Fatal("Did not decode the images", draft)
+	}
+	if mockClient.operations[0] != GET_DRAFT_MODULE_IMAGES {
+		t.Fatal("Unexpected operation", mockClient.operations)
+	}
+}
+
+// The operations that existed before images had ids keep their selections,
+// the image fields are only selected by the operations of the image APIs
+func TestExistingOperationsDoNotSelectImages(t *testing.T) {
+	for _, document := range []string{GET_PUBLISHED_APP_TILE_MODULE, GET_DRAFT_MODULE, FINALIZE_IMAGE_UPLOAD} {
+		if strings.Contains(document, "ImageFields") || strings.Contains(document, "images") {
+			t.Fatal("Should not select the image fields", document)
+		}
+	}
+}
@@ -0,0 +1,224 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+)
+
+// Logger receives structured log records from the client. The arguments
+// alternate between keys and values, so a *slog.Logger can be used directly.
+type Logger interface {
+	Debug(msg string, args ...interface{})
+	Info(msg string, args ...interface{})
+	Warn(msg string, args ...interface{})
+	Error(msg string, args ...interface{})
+}
+
+type stdLogger struct {
+	logger *log.Logger
+}
+
+// NewStdLogger adapts a standard library logger, writing records as
+// `LEVEL msg key=value ...` lines
+func NewStdLogger(logger *log.Logger) Logger {
+	return &stdLogger{logger: logger}
+}
+
+func (l *stdLogger) write(level string, msg string, args []interface{}) {
+	var line strings.Builder
+	line.WriteString(level)
+	line.WriteString(" ")
+	line.WriteString(msg)
+	for i := 0; i < len(args); i += 2 {
+		if i+1 == len(args) {
+			fmt.Fprintf(&line, " !BADKEY=%v", args[i])
+			break
+		}
+		fmt.Fprintf(&line, " %v=", args[i])
+		switch value := args[i+1].(type) {
+		case string, fmt.Stringer, error, int, int64, float64, bool, nil:
+			fmt.Fprintf(&line, "%q", fmt.Sprint(value))
+		default:
+			encoded, err := json.Marshal(value)
+			if err != nil {
+				fmt.Fprintf(&line, "%q", fmt.Sprint(value))
+			} else {
+				line.Write(encoded)
+			}
+		}
+	}
+	l.logger.Println(line.String())
+}
+
+func (l *stdLogger) Debug(msg string, args ...interface{}) { l.write("DEBUG", msg, args) }
+func (l *stdLogger) Info(msg string, args ...interface{})  { l.write("INFO", msg, args) }
+func (l *stdLogger) Warn(msg string, args ...interface{})  { l.write("WARN", msg, args) }
+func (l *stdLogger) Error(msg string, args ...interface{}) { l.write("ERROR", msg, args) }
+
+const REDACTED = "[REDACTED]"
+
+// Headers that are always masked before a request is logged
+var DEFAULT_REDACTED_HEADERS = []string{
+	"LifeOmic-Policy",
+	"Authorization",
+	"Cookie",
+	"Set-Cookie",
+	"X-Api-Key",
+}
+
+// Redactor masks sensitive headers and variables before they are logged.
+//
+// Variable paths are dot separated keys, `*` matches any key or list index and
+// lists are otherwise traversed transparently, so `input.patients.name` masks
+// the name of every patient.
+type Redactor struct {
+	Headers       []string
+	VariablePaths []string
+}
+
+func (r *Redactor) RedactHeaders(headers map[string]string) map[string]string {
+	redacted := make(map[string]string, len(headers))
+	for key, value := range headers {
+		if containsFold(DEFAULT_REDACTED_HEADERS, key) || containsFold(r.Headers, key) {
+			redacted[key] = REDACTED
+		} else {
+			redacted[key] = value
+		}
+	}
+	return redacted
+}
+
+func containsFold(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// RedactVariables returns a copy of the variables with every configured path
+// masked, the original variables are left untouched
+func (r *Redactor) RedactVariables(variables map[string]interface{}) map[string]interface{} {
+	var redacted interface{} = copyValue(variables)
+	for _, path := range r.VariablePaths {
+		redacted = redactPath(redacted, strings.Split(path, "."))
+	}
+	result, _ := redacted.(map[string]interface{})
+	return result
+}
+
+func copyValue(value interface{}) interface{} {
+	switch value := value.(type) {
+	case map[string]interface{}:
+		copied := make(map[string]interface{}, len(value))
+		for k, v := range value {
+			copied[k] = copyValue(v)
+		}
+		return copied
+	case []interface{}:
+		copied := make([]interface{}, len(value))
+		for i, v := range value {
+			copied[i] = copyValue(v)
+		}
+		return copied
+	default:
+		// Typed values such as structs are normalized through JSON so nested
+		// fields can be masked as well
+		encoded, err := json.Marshal(value)
+		if err != nil {
+			return value
+		}
+		var normalized interface{}
+		if err := json.Unmarshal(encoded, &normalized); err != nil {
+			return value
+		}
+		if _, ok := normalized.(map[string]interface{}); ok {
+			return normalized
+		}
+		if _, ok := normalized.([]interface{}); ok {
+			return normalized
+		}
+		return value
+	}
+}
+
+func redactPath(value interface{}, path []string) interface{} {
+	if len(path) == 0 {
+		return REDACTED
+	}
+	switch value := value.(type) {
+	case map[string]interface{}:
+		for key, nested := range value {
+			if path[0] == "*" || path[0] == key {
+				value[key] = redactPath(nested, path[1:])
+			}
+		}
+		return value
+	case []interface{}:
+		for i, nested := range value {
+			if path[0] == "*" {
+				value[i] = redactPath(nested, path[1:])
+			} else {
+				value[i] = redactPath(nested, path)
+			}
+		}
+		return value
+	default:
+		return value
+	}
+}
+
+// logRequest writes a debug record for a lambda invocation. The payload is
+// decoded again so the same code handles both Gql and Do requests.
+func (c *LambdaClient) logRequest(request invocationRequest) {
+	if c.logger == nil {
+		return
+	}
+	var sent payload
+	if err := json.Unmarshal(request.payload, &sent); err != nil {
+		return
+	}
+	args := []interface{}{
+		"functionName", request.functionName,
+		"method", sent.HttpMethod,
+		"path", request.path,
+		"operation", request.operation,
+		"headers", c.redactor.RedactHeaders(sent.Headers),
+	}
+	var body struct {
+		Variables map[string]interface{} `json:"variables"`
+	}
+	if err := json.Unmarshal([]byte(sent.Body), &body); err == nil && body.Variables != nil {
+		args = append(args, "variables", c.redactor.RedactVariables(body.Variables))
+	}
+	c.logger.Debug("phc request", args...)
+}
+
+func (c *LambdaClient) logResponse(invocation Invocation, raw []byte, err error) {
+	if c.logger == nil {
+		return
+	}
+	args := []interface{}{
+		"functionName", invocation.Labels.FunctionName,
+		"path", invocation.Labels.Path,
+		"operation", invocation.Labels.Operation,
+		"duration", invocation.Duration.Round(time.Millisecond).String(),
+		"responseBytes", invocation.ResponseBytes,
+	}
+	if err != nil {
+		c.logger.Debug("phc request failed", append(args, "error", err)...)
+		return
+	}
+	var received responsePayload
+	if json.Unmarshal(raw, &received) == nil {
+		args = append(args, "statusCode", received.StatusCode)
+	}
+	if invocation.ErrorClass != "" {
+		args = append(args, "errorClass", invocation.ErrorClass)
+	}
+	c.logger.Debug("phc response", args...)
+}
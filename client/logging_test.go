@@ -0,0 +1,87 @@
+package client
+
+import (
+	"bytes"
+	"log"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/lambda"
+)
+
+func TestRedactVariables(t *testing.T) {
+	redactor := Redactor{VariablePaths: []string{"input.patients.name", "input.notes.*"}}
+	variables := map[string]interface{}{
+		"input": map[string]interface{}{
+			"patients": []interface{}{
+				map[string]interface{}{"name": "Jane Doe", "id": "1"},
+				map[string]interface{}{"name": "John Doe", "id": "2"},
+			},
+			"notes": []interface{}{"private", "notes"},
+			"title": "visible",
+		},
+	}
+	redacted := redactor.RedactVariables(variables)
+	input := redacted["input"].(map[string]interface{})
+	patients := input["patients"].([]interface{})
+	for _, patient := range patients {
+		if patient.(map[string]interface{})["name"] != REDACTED {
+			t.Fatal("Did not redact patient name", patient)
+		}
+		if patient.(map[string]interface{})["id"] == REDACTED {
+			t.Fatal("Redacted an unconfigured path", patient)
+		}
+	}
+	if input["notes"].([]interface{})[0] != REDACTED || input["title"] != "visible" {
+		t.Fatal("Did not redact the right fields", input)
+	}
+
+	original := variables["input"].(map[string]interface{})["patients"].([]interface{})[0]
+	if original.(map[string]interface{})["name"] != "Jane Doe" {
+		t.Fatal("Modified the original variables", original)
+	}
+}
+
+func TestRedactHeaders(t *testing.T) {
+	redactor := Redactor{Headers: []string{"X-Secret"}}
+	redacted := redactor.RedactHeaders(map[string]string{
+		"LifeOmic-Policy":  "{}",
+		"authorization":    "Bearer token",
+		"x-secret":         "value",
+		"LifeOmic-Account": "lifeomic",
+	})
+	if redacted["LifeOmic-Policy"] != REDACTED || redacted["authorization"] != REDACTED || redacted["x-secret"] != REDACTED {
+		t.Fatal("Did not redact sensitive headers", redacted)
+	}
+	if redacted["LifeOmic-Account"] != "lifeomic" {
+		t.Fatal("Redacted a header that is not sensitive", redacted)
+	}
+}
+
+func TestGqlLogsRedactedRequests(t *testing.T) {
+	mock := MockInvoker{
+		response: &lambda.InvokeOutput{
+			Payload: []byte("{ \"body\": \"{ \\\"data\\\": { \\\"result\\\": true }}\", \"statusCode\": 200 }"),
+		},
+	}
+	output := bytes.Buffer{}
+	client := LambdaClient{invoker: &mock, rules: map[string]bool{"secretRule": true}}
+	WithLogger(NewStdLogger(log.New(&output, "", 0)))(&client)
+	WithRedactedVariables("var")(&client)
+
+	_, err := client.Gql("some_lambda:status/some/path", MOCK_MUTATION, map[string]interface{}{"var": "sensitive value"})
+	if err != nil {
+		t.Fatal("Unexpected test Error", err)
+	}
+
+	logged := output.String()
+	if !strings.Contains(logged, "DEBUG phc request") || !strings.Contains(logged, "DEBUG phc response") {
+		t.Fatal("Did not log request and response", logged)
+	}
+	if strings.Contains(logged, "sensitive value") || strings.Contains(logged, "secretRule") {
+		t.Fatal("Logged sensitive values", logged)
+	}
+	if !strings.Contains(logged, `operation="MockMutation"`) {
+		t.Fatal("Did not log the operation", logged)
+	}
+}
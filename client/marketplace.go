@@ -1,302 +1,635 @@
 package client
 
 import (
-	"bytes"
-	"io"
-	"mime/multipart"
+	"context"
+	"errors"
 	"net/http"
-	"os"
 	"path"
+	"reflect"
 
-	"github.com/mitchellh/mapstructure"
+	"github.com/lifeomic/phc-sdk-go/graphql"
 )
 
 type MarketplaceClient struct {
 	graphqlUrl string
 	client     graphqlClient
+	guard      IdempotencyGuard
+	// validateImages checks images before they are uploaded, see
+	// WithImageValidation
+	validateImages bool
+	// dryRun skips image uploads, the upload urls of a dry run are synthetic
+	dryRun bool
+	// httpClient sends image uploads and downloads, http.DefaultClient when
+	// nil
+	httpClient *http.Client
+	// moduleSources are the source types added with RegisterModuleSource,
+	// shared with the LambdaClient
+	moduleSources *moduleSourceRegistry
+}
+
+func (self *MarketplaceClient) imageHttpClient() *http.Client {
+	if self.httpClient == nil {
+		return http.DefaultClient
+	}
+	return self.httpClient
 }
 
 func (self *MarketplaceClient) Gql(query string, variables map[string]interface{}) (*map[string]interface{}, error) {
 	return self.client.Gql(self.graphqlUrl, query, variables)
 }
 
-const GET_PUBLISHED_APP_TILE_MODULE = `
-  query GetPublishedModule($id: ID!, $version: String) {
-    myModule(moduleId: $id, version: $version) {
-      title
-      description
-	  version
-	  source {
-		... on AppTile {
-		  id
-		}
-	  }
-	  iconV2 {
-		url
-		fileName
-		fileExtension
-	  }
-    }
-  }
-`
-
-const CREATE_DRAFT_MODULE = `
- mutation CreateDraftModule($input: CreateDraftModuleInput!) {
-   createDraftModule(input: $input) {
-     id
-   }
- }
-`
-
-const SET_APP_TILE = `
-  mutation SetAppTile($input: SetPublicAppTileDraftModuleSourceInput!) {
-	setPublicAppTileDraftModuleSource(input: $input) {
-	  moduleId
-	}
-  }
-`
-
-const PUBLISH_MODULE = `
-  mutation PublishModule($input: PublishDraftModuleInputV2!) {
-	publishDraftModuleV2(input: $input) {
-	  id
-	  version {
-		version
-	  }
-	}
-  }
-`
-
-const START_IMAGE_UPLOAD = `
-  mutation StartImageUpload($input: StartUploadInput!) {
-	startUpload(input: $input) {
-	  id
-	  url
-	  fields
-	}
-  }
-`
-
-const FINALIZE_IMAGE_UPLOAD = `
-  mutation FinalizeImageUpload($input: FinalizeUploadInput!) {
-	finalizeUpload(input: $input) {
-	  moduleId
-	}
-  }
-`
-
-type AppTileModule struct {
+func (self *MarketplaceClient) GqlWithContext(ctx context.Context, query string, variables map[string]interface{}, options ...GqlOption) (*map[string]interface{}, error) {
+	return self.client.GqlWithContext(ctx, self.graphqlUrl, query, variables, options...)
+}
+
+func (self *MarketplaceClient) GqlWithResponse(ctx context.Context, query string, variables map[string]interface{}, options ...GqlOption) (*GqlResponse, error) {
+	return self.client.GqlWithResponse(ctx, self.graphqlUrl, query, variables, options...)
+}
+
+// The operations are defined in operations/marketplace.graphql and
+// marketplace_gen.go is generated from them, run `go generate` after changing
+// either the operations or the schema snapshot
+const (
+	GET_PUBLISHED_APP_TILE_MODULE = GetPublishedModuleDocument
+	CREATE_DRAFT_MODULE           = CreateDraftModuleDocument
+	SET_APP_TILE                  = SetAppTileDocument
+	PUBLISH_MODULE                = PublishModuleDocument
+	START_IMAGE_UPLOAD            = StartImageUploadDocument
+	FINALIZE_IMAGE_UPLOAD         = FinalizeImageUploadDocument
+	LIST_MODULES                  = ListModulesDocument
+	GET_DRAFT_MODULE              = GetDraftModuleDocument
+	LIST_DRAFT_MODULES            = ListDraftModulesDocument
+	UPDATE_DRAFT_MODULE           = UpdateDraftModuleDocument
+	DELETE_DRAFT_MODULE           = DeleteDraftModuleDocument
+	DELETE_MODULE                 = DeleteModuleDocument
+	LIST_MODULE_VERSIONS          = ListModuleVersionsDocument
+	GET_MODULE_STATUS             = GetModuleStatusDocument
+	REORDER_DRAFT_MODULE_IMAGES   = ReorderDraftModuleImagesDocument
+	REMOVE_DRAFT_MODULE_IMAGE     = RemoveDraftModuleImageDocument
+	GET_APP_TILE_MODULE_VERSION   = GetAppTileModuleVersionDocument
+	GET_DRAFT_MODULE_IMAGES       = GetDraftModuleImagesDocument
+	FINALIZE_MODULE_IMAGE_UPLOAD  = FinalizeModuleImageUploadDocument
+)
+
+type AppTileModule = GetPublishedModuleMyModule
+
+// GetAppTileModule returns the latest published version of the module, see
+// GetAppTileModuleVersion for other versions
+func (self *MarketplaceClient) GetAppTileModule(id string) (*AppTileModule, error) {
+	res, err := GetPublishedModule(context.Background(), self, GetPublishedModuleVariables{Id: id})
+	if err != nil {
+		return nil, err
+	}
+	return res.MyModule, nil
+}
+
+// Module is a published module with a typed source, see GetModule
+type Module struct {
+	Id          string
 	Title       string
 	Description string
 	Version     string
-	Source      struct {
-		Id string
-	}
-	IconV2 *struct {
-		Url           string
-		FileName      string
-		FileExtension string
+	Category    ModuleCategory
+	Source      ModuleSource
+	IconV2      *ModuleImage
+	Images      []ModuleImage
+}
+
+// imageFields selects the fields of the ImageFields fragment, so queries built
+// at runtime decode into ModuleImage like the generated operations
+func imageFields() []graphql.Selection {
+	fragment := reflect.TypeOf(ImageFields{})
+	names := make([]string, 0, fragment.NumField())
+	for i := 0; i < fragment.NumField(); i++ {
+		names = append(names, fragment.Field(i).Tag.Get("json"))
 	}
+	return graphql.Fields(names...)
 }
 
-func (self *MarketplaceClient) GetAppTileModule(id string) (*AppTileModule, error) {
-	res, err := self.Gql(GET_PUBLISHED_APP_TILE_MODULE, map[string]interface{}{"id": id})
+// moduleQuery selects the source fields of every registered source type, so
+// it is built when it is used
+func (self *MarketplaceClient) moduleQuery() string {
+	return graphql.Query("GetModule",
+		graphql.Field("myModule").
+			Arg("moduleId", graphql.Var("id", "ID!")).
+			Arg("version", graphql.Var("version", "String")).
+			Select(graphql.Fields("id", "title", "description", "version", "category")...).
+			Select(
+				self.moduleSourceSelection(),
+				graphql.Field("iconV2", imageFields()...),
+				graphql.Field("images", imageFields()...),
+			),
+	).MustBuild()
+}
+
+// GetModule returns the published module of any category with its source
+// decoded by type, or nil if there is none with the id
+func (self *MarketplaceClient) GetModule(id string) (*Module, error) {
+	return self.getModule(id, nil)
+}
+
+// GetModuleVersion is GetModule for a published version of the module
+func (self *MarketplaceClient) GetModuleVersion(id string, version string) (*Module, error) {
+	return self.getModule(id, &version)
+}
+
+func (self *MarketplaceClient) getModule(id string, version *string) (*Module, error) {
+	variables := map[string]interface{}{"id": id}
+	if version != nil {
+		variables["version"] = *version
+	}
+	res, err := self.GqlWithContext(context.Background(), self.moduleQuery(), variables)
 	if err != nil {
 		return nil, err
 	}
-	var data struct {
-		MyModule *AppTileModule
+	var decoded struct {
+		MyModule *struct {
+			Id          string                 `json:"id"`
+			Title       string                 `json:"title"`
+			Description string                 `json:"description"`
+			Version     string                 `json:"version"`
+			Category    ModuleCategory         `json:"category"`
+			Source      map[string]interface{} `json:"source"`
+			IconV2      *ModuleImage           `json:"iconV2"`
+			Images      []ModuleImage          `json:"images"`
+		} `json:"myModule"`
+	}
+	if err := DecodeData(res, &decoded); err != nil {
+		return nil, err
 	}
-	err = mapstructure.Decode(res, &data)
+	if decoded.MyModule == nil {
+		return nil, nil
+	}
+	source, err := self.DecodeModuleSource(decoded.MyModule.Source)
 	if err != nil {
 		return nil, err
 	}
-	return data.MyModule, nil
+	return &Module{
+		Id:          decoded.MyModule.Id,
+		Title:       decoded.MyModule.Title,
+		Description: decoded.MyModule.Description,
+		Version:     decoded.MyModule.Version,
+		Category:    decoded.MyModule.Category,
+		Source:      source,
+		IconV2:      decoded.MyModule.IconV2,
+		Images:      decoded.MyModule.Images,
+	}, nil
 }
 
-type AppTileCreate struct {
-	Name           string
-	Description    string
-	Image          string
-	AppTileId      string
-	Version        string
-	ParentModuleId *string
+type ModuleSummary = ListModulesMyModulesEdgesNode
+type DraftModuleSummary = ListDraftModulesMyDraftModulesEdgesNode
+type DraftModule = GetDraftModuleMyDraftModule
+type DraftModuleImages = GetDraftModuleImagesMyDraftModule
+
+// ListModules iterates over my published modules
+func (self *MarketplaceClient) ListModules(options ...PageOption) *Iterator[ModuleSummary] {
+	return Paginate[ModuleSummary](self, LIST_MODULES, nil, "myModules", options...)
+}
+
+// ListDraftModules iterates over my draft modules, including drafts of new
+// versions of published modules
+func (self *MarketplaceClient) ListDraftModules(options ...PageOption) *Iterator[DraftModuleSummary] {
+	return Paginate[DraftModuleSummary](self, LIST_DRAFT_MODULES, nil, "myDraftModules", options...)
 }
 
-func postImageToUrl(url string, image string, file_name string, fields map[string]string) error {
-	file, err := os.Open(image)
+// GetDraftModule returns the draft module, or nil if there is none with the id
+func (self *MarketplaceClient) GetDraftModule(id string) (*DraftModule, error) {
+	res, err := GetDraftModule(context.Background(), self, GetDraftModuleVariables{Id: id})
 	if err != nil {
-		return err
+		return nil, err
 	}
+	return res.MyDraftModule, nil
+}
+
+// GetDraftModuleImages returns the icon and the other images of the draft
+// module, or nil if there is none with the id
+func (self *MarketplaceClient) GetDraftModuleImages(id string) (*DraftModuleImages, error) {
+	res, err := GetDraftModuleImages(context.Background(), self, GetDraftModuleImagesVariables{Id: id})
+	if err != nil {
+		return nil, err
+	}
+	return res.MyDraftModule, nil
+}
 
-	body := &bytes.Buffer{}
-	writer := multipart.NewWriter(body)
-	for key, val := range fields {
-		err = writer.WriteField(key, val)
+// DraftModuleUpdate holds the changes to a draft module, fields that are not
+// set are left as they are
+type DraftModuleUpdate struct {
+	Title       Optional[string]
+	Description Optional[string]
+	// Source replaces the source when it is not nil, it must be of the
+	// draft's category
+	Source ModuleSource
+}
+
+// UpdateDraftModule changes the title, description and source of a draft
+// module
+func (self *MarketplaceClient) UpdateDraftModule(id string, update DraftModuleUpdate) error {
+	ctx := context.Background()
+	if update.Title.IsSet() || update.Description.IsSet() {
+		_, err := UpdateDraftModule(ctx, self, UpdateDraftModuleVariables{
+			Input: UpdateDraftModuleInput{
+				ModuleId:    id,
+				Title:       update.Title,
+				Description: update.Description,
+			},
+		})
 		if err != nil {
 			return err
 		}
 	}
-	part, err := writer.CreateFormFile("file", file_name)
-	if err != nil {
-		return err
+	if update.Source != nil {
+		return update.Source.SetDraftSource(ctx, self, id)
 	}
-	_, err = io.Copy(part, file)
+	return nil
+}
+
+// DeleteDraftModule deletes an unpublished draft. Published versions of the
+// module are not affected.
+func (self *MarketplaceClient) DeleteDraftModule(id string) error {
+	_, err := DeleteDraftModule(context.Background(), self, DeleteDraftModuleVariables{
+		Input: DeleteDraftModuleInput{ModuleId: id},
+	})
+	return err
+}
+
+// DeleteModule deletes a published module with all of its versions
+func (self *MarketplaceClient) DeleteModule(id string) error {
+	_, err := DeleteModule(context.Background(), self, DeleteModuleVariables{
+		Input: DeleteModuleInput{ModuleId: id},
+	})
+	return err
+}
+
+// ListModuleVersions returns every published version of the module, or nil
+// if there is no module with the id
+func (self *MarketplaceClient) ListModuleVersions(id string) ([]string, error) {
+	return self.listModuleVersions(context.Background(), id)
+}
+
+func (self *MarketplaceClient) listModuleVersions(ctx context.Context, id string) ([]string, error) {
+	res, err := ListModuleVersions(ctx, self, ListModuleVersionsVariables{Id: id})
 	if err != nil {
-		return err
+		return nil, err
+	}
+	if res.MyModule == nil {
+		return nil, nil
+	}
+	versions := make([]string, 0, len(res.MyModule.Versions))
+	for _, version := range res.MyModule.Versions {
+		versions = append(versions, version.Version)
 	}
+	return versions, nil
+}
 
-	err = writer.Close()
+// LatestModuleVersion returns the highest published version of the module,
+// or nil if none of its versions is a semantic version
+func (self *MarketplaceClient) LatestModuleVersion(id string) (*Version, error) {
+	versions, err := self.ListModuleVersions(id)
 	if err != nil {
-		return err
+		return nil, err
 	}
+	return latestVersion(versions), nil
+}
 
-	req, err := http.NewRequest("POST", url, body)
-	req.Header.Set("Content-Type", writer.FormDataContentType())
+// NextModuleVersion bumps the latest published version of the module, a
+// module without versions starts from 0.0.0
+func (self *MarketplaceClient) NextModuleVersion(id string, bump VersionBump) (Version, error) {
+	latest, err := self.LatestModuleVersion(id)
 	if err != nil {
-		return err
+		return Version{}, err
 	}
-	client := &http.Client{}
-	resp, err := client.Do(req)
-	if err != nil {
-		return err
+	if latest == nil {
+		latest = &Version{}
 	}
-	responseBody := &bytes.Buffer{}
-	responseBody.ReadFrom(resp.Body)
-	resp.Body.Close()
-	return nil
+	return latest.Next(bump)
+}
+
+type AppTileCreate struct {
+	Name        string
+	Description string
+	Image       string
+	// Icon is attached instead of the file at Image when it is set
+	Icon           *ImageUpload
+	AppTileId      string
+	Version        string
+	ParentModuleId *string
+	// IdempotencyKey is described in ModuleCreate
+	IdempotencyKey string
+	// KeepPartialDraft and ResumeFrom are described in ModuleCreate
+	KeepPartialDraft bool
+	ResumeFrom       *PartialDraftError
 }
 
+// AttachImageToDraftModule uploads the icon at the image path to the draft
+// module
 func (self *MarketplaceClient) AttachImageToDraftModule(moduleId string, image string) error {
-	fileName := path.Base(image)
-	startResponse, err := self.Gql(START_IMAGE_UPLOAD, map[string]interface{}{
-		"input": map[string]interface{}{
-			"fileName": fileName,
-		},
-	})
-	if err != nil {
+	return self.attachImageFile(context.Background(), moduleId, image)
+}
+
+// AttachIconToDraftModule uploads the icon to the draft module, with
+// WithImageValidation it is checked against ICON_IMAGE_RULES first
+func (self *MarketplaceClient) AttachIconToDraftModule(moduleId string, icon *ImageUpload) error {
+	if err := self.checkImage(ImageTypeIcon, icon); err != nil {
 		return err
 	}
-	var startData struct {
-		StartUpload struct {
-			Fields map[string]string
-			Url    string
-			Id     string
-		}
+	return self.attachUpload(context.Background(), moduleId, ImageTypeIcon, icon)
+}
+
+// AttachDraftModuleImage adds the image after the images of the same type on
+// the draft module. With WithImageValidation it is checked against the rules
+// of its type first, see ImageRulesFor.
+func (self *MarketplaceClient) AttachDraftModuleImage(moduleId string, imageType ImageType, image *ImageUpload) (*ModuleImage, error) {
+	if err := self.checkImage(imageType, image); err != nil {
+		return nil, err
 	}
-	err = mapstructure.Decode(startResponse, &startData)
+	ctx := context.Background()
+	uploadId, err := self.uploadImage(ctx, image.FileName, func() (*ImageUpload, error) {
+		return image, nil
+	})
 	if err != nil {
-		return err
+		return nil, err
 	}
-
-	err = postImageToUrl(startData.StartUpload.Url, image, fileName, startData.StartUpload.Fields)
+	res, err := FinalizeModuleImageUpload(ctx, self, FinalizeModuleImageUploadVariables{
+		Input: FinalizeUploadInput{Id: uploadId, ModuleId: moduleId, Type: imageType},
+	})
 	if err != nil {
-		return err
+		return nil, err
 	}
+	return res.FinalizeUpload.Image, nil
+}
 
-	finalizeResponse, err := self.Gql(FINALIZE_IMAGE_UPLOAD, map[string]interface{}{
-		"input": map[string]string{
-			"id":       startData.StartUpload.Id,
-			"moduleId": moduleId,
-			"type":     "ICON",
-		},
-	})
-
-	if err != nil {
+// checkImage validates the image against the rules of its type when image
+// validation is enabled
+func (self *MarketplaceClient) checkImage(imageType ImageType, image *ImageUpload) error {
+	if !self.validateImages {
 		return nil
 	}
+	return image.Validate(ImageRulesFor(imageType))
+}
 
-	var finalizeData struct {
-		FinalizeUpload struct {
-			ModuleId string
+// attachImageFile uploads the icon at the path, it is checked once it is
+// loaded
+func (self *MarketplaceClient) attachImageFile(ctx context.Context, moduleId string, image string) error {
+	return self.attachImage(ctx, moduleId, ImageTypeIcon, path.Base(image), func() (*ImageUpload, error) {
+		upload, err := ImageFromFile(image)
+		if err != nil {
+			return nil, err
 		}
-	}
+		if err := self.checkImage(ImageTypeIcon, upload); err != nil {
+			return nil, err
+		}
+		return upload, nil
+	})
+}
 
-	err = mapstructure.Decode(finalizeResponse, &finalizeData)
-	return err
+// attachUpload uploads an image that was already checked
+func (self *MarketplaceClient) attachUpload(ctx context.Context, moduleId string, imageType ImageType, image *ImageUpload) error {
+	return self.attachImage(ctx, moduleId, imageType, image.FileName, func() (*ImageUpload, error) {
+		return image, nil
+	})
 }
 
-func (self *MarketplaceClient) CreateAppTileDraftModule(params AppTileCreate) (*string, error) {
-	res, err := self.Gql(CREATE_DRAFT_MODULE, map[string]interface{}{"input": map[string]interface{}{
-		"title":       params.Name,
-		"description": params.Description,
-		// "iconV2":         params.Image, // Use upload
-		"parentModuleId": params.ParentModuleId,
-		"category":       "APP_TILE",
-	}})
+// attachImage uploads the image and finalizes it as an image of the type on
+// the draft module
+func (self *MarketplaceClient) attachImage(ctx context.Context, moduleId string, imageType ImageType, fileName string, load func() (*ImageUpload, error)) error {
+	uploadId, err := self.uploadImage(ctx, fileName, load)
+	if err != nil {
+		return err
+	}
+	_, err = FinalizeImageUpload(ctx, self, FinalizeImageUploadVariables{
+		Input: FinalizeUploadInput{Id: uploadId, ModuleId: moduleId, Type: imageType},
+	})
+	return err
+}
 
+// uploadImage starts an upload and posts the image to it, returning the
+// upload id. The image is only loaded once the upload is started, dry runs
+// don't upload anything so the image doesn't need to exist.
+func (self *MarketplaceClient) uploadImage(ctx context.Context, fileName string, load func() (*ImageUpload, error)) (string, error) {
+	startData, err := StartImageUpload(ctx, self, StartImageUploadVariables{
+		Input: StartUploadInput{FileName: fileName},
+	})
 	if err != nil {
-		return nil, err
+		return "", err
+	}
+	if self.dryRun {
+		return startData.StartUpload.Id, nil
+	}
+	image, err := load()
+	if err != nil {
+		return "", err
+	}
+	err = postImage(ctx, self.imageHttpClient(), startData.StartUpload.Url, startData.StartUpload.Fields, image)
+	if err != nil {
+		return "", err
 	}
+	return startData.StartUpload.Id, nil
+}
 
-	var createDraftData struct {
-		CreateDraftModule struct {
-			Id string
-		}
+// ReorderDraftModuleImages sets the order of the draft module's images of the
+// type, imageIds must list every image of the type
+func (self *MarketplaceClient) ReorderDraftModuleImages(moduleId string, imageType ImageType, imageIds []string) ([]ModuleImage, error) {
+	res, err := ReorderDraftModuleImages(context.Background(), self, ReorderDraftModuleImagesVariables{
+		Input: ReorderDraftModuleImagesInput{ModuleId: moduleId, Type: imageType, ImageIds: imageIds},
+	})
+	if err != nil {
+		return nil, err
 	}
+	return res.ReorderDraftModuleImages.Images, nil
+}
 
-	err = mapstructure.Decode(res, &createDraftData)
+// RemoveDraftModuleImage removes the image from the draft module and returns
+// the remaining images
+func (self *MarketplaceClient) RemoveDraftModuleImage(moduleId string, imageId string) ([]ModuleImage, error) {
+	res, err := RemoveDraftModuleImage(context.Background(), self, RemoveDraftModuleImageVariables{
+		Input: RemoveDraftModuleImageInput{ModuleId: moduleId, ImageId: imageId},
+	})
 	if err != nil {
 		return nil, err
 	}
+	return res.RemoveDraftModuleImage.Images, nil
+}
+
+// ModuleCreate holds the fields of a new module of any category
+type ModuleCreate struct {
+	Name        string
+	Description string
+	// Image is the path of the icon, it is read and checked before the draft
+	// is created
+	Image string
+	// Icon is attached instead of the file at Image when it is set
+	Icon           *ImageUpload
+	Source         ModuleSource
+	Version        string
+	ParentModuleId *string
+	// IdempotencyKey is sent with the mutations of CreateDraftModule and
+	// PublishNewModule. With an IdempotencyGuard an existing draft created
+	// with the same key is reused instead of creating a new one.
+	IdempotencyKey string
+	// KeepPartialDraft keeps the draft when a step after its creation fails,
+	// by default it is deleted. Either way a *PartialDraftError is returned.
+	KeepPartialDraft bool
+	// ResumeFrom continues a draft kept after a failure from the failed step,
+	// the draft is kept again when a later step fails
+	ResumeFrom *PartialDraftError
+}
 
-	moduleId := createDraftData.CreateDraftModule.Id
+func (params AppTileCreate) moduleCreate() ModuleCreate {
+	return ModuleCreate{
+		Name:             params.Name,
+		Description:      params.Description,
+		Image:            params.Image,
+		Icon:             params.Icon,
+		Source:           &AppTileSource{Id: params.AppTileId},
+		Version:          params.Version,
+		ParentModuleId:   params.ParentModuleId,
+		IdempotencyKey:   params.IdempotencyKey,
+		KeepPartialDraft: params.KeepPartialDraft,
+		ResumeFrom:       params.ResumeFrom,
+	}
+}
 
-	res, err = self.Gql(SET_APP_TILE, map[string]interface{}{"input": map[string]interface{}{
-		"moduleId": moduleId,
-		"sourceInfo": map[string]string{
-			"id": params.AppTileId,
-		},
-	}})
+func (self *MarketplaceClient) CreateAppTileDraftModule(params AppTileCreate) (*string, error) {
+	return self.CreateDraftModule(params.moduleCreate())
+}
 
+// CreateDraftModule creates a draft module of the source's category, sets its
+// source and attaches the icon. When setting the source or attaching the icon
+// fails a *PartialDraftError is returned, see ModuleCreate.KeepPartialDraft.
+func (self *MarketplaceClient) CreateDraftModule(params ModuleCreate) (*string, error) {
+	ctx := context.Background()
+	if params.IdempotencyKey != "" {
+		ctx = WithIdempotencyKey(ctx, params.IdempotencyKey)
+	}
+	progress, err := self.createModuleDraft(ctx, params)
 	if err != nil {
 		return nil, err
 	}
+	return &progress.moduleId, nil
+}
 
-	var setAppTileData struct {
-		SetPublicAppTileDraftModuleSource struct {
-			ModuleId string
+// createModuleDraft creates the draft, sets its source and attaches the icon.
+// A failure after the draft is created is returned as a PartialDraftError.
+func (self *MarketplaceClient) createModuleDraft(ctx context.Context, params ModuleCreate) (*draftProgress, error) {
+	if params.Source == nil {
+		return nil, errors.New("a module source is required")
+	}
+	progress, err := newDraftProgress(params.ResumeFrom)
+	if err != nil {
+		return nil, err
+	}
+	// The icon is loaded and checked before the draft is created, dry runs
+	// don't upload it so the file doesn't need to exist
+	icon := params.Icon
+	if icon == nil && params.Image != "" && !self.dryRun && !progress.resumed.Done(DRAFT_STEP_IMAGE) {
+		if icon, err = ImageFromFile(params.Image); err != nil {
+			return nil, err
+		}
+	}
+	if icon != nil {
+		if err := self.checkImage(ImageTypeIcon, icon); err != nil {
+			return nil, err
 		}
 	}
-	err = mapstructure.Decode(res, &setAppTileData)
+	err = progress.run(DRAFT_STEP_CREATE, func() error {
+		draftModuleId, err := createOnce(ctx, self.guard, IDEMPOTENCY_KIND_DRAFT_MODULE, params.IdempotencyKey, func(ctx context.Context) (*string, error) {
+			return self.createDraftModule(ctx, params)
+		})
+		if err != nil {
+			return err
+		}
+		progress.moduleId = *draftModuleId
+		return nil
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	err = self.AttachImageToDraftModule(moduleId, params.Image)
+	err = progress.run(DRAFT_STEP_SOURCE, func() error {
+		return params.Source.SetDraftSource(deriveIdempotencyKey(ctx, "source"), self, progress.moduleId)
+	})
+	if err != nil {
+		return nil, progress.fail(ctx, self, params, DRAFT_STEP_SOURCE, err)
+	}
 
+	err = progress.run(DRAFT_STEP_IMAGE, func() error {
+		ctx := deriveIdempotencyKey(ctx, "image")
+		switch {
+		case icon != nil:
+			return self.attachUpload(ctx, progress.moduleId, ImageTypeIcon, icon)
+		case params.Image != "":
+			return self.attachImageFile(ctx, progress.moduleId, params.Image)
+		}
+		return nil
+	})
 	if err != nil {
-		return nil, err
+		return nil, progress.fail(ctx, self, params, DRAFT_STEP_IMAGE, err)
 	}
 
-	return &moduleId, nil
+	return progress, nil
 }
 
-func (self *MarketplaceClient) PublishNewAppTileModule(params AppTileCreate) (*string, error) {
-	draftModuleId, err := self.CreateAppTileDraftModule(params)
+func (self *MarketplaceClient) createDraftModule(ctx context.Context, params ModuleCreate) (*string, error) {
+	res, err := CreateDraftModule(ctx, self, CreateDraftModuleVariables{
+		Input: CreateDraftModuleInput{
+			Title:       params.Name,
+			Description: params.Description,
+			// Icons are attached with an upload after the draft is created
+			ParentModuleId: FromPointer(params.ParentModuleId),
+			Category:       params.Source.Category(),
+		},
+	})
 	if err != nil {
 		return nil, err
 	}
-	publishRes, err := self.Gql(PUBLISH_MODULE, map[string]interface{}{"input": map[string]interface{}{
-		"moduleId": draftModuleId,
-		"version": map[string]string{
-			"version": params.Version,
-		},
-	}})
-	if err != nil {
+	return &res.CreateDraftModule.Id, nil
+}
+
+func (self *MarketplaceClient) PublishNewAppTileModule(params AppTileCreate) (*string, error) {
+	return self.PublishNewModule(params.moduleCreate())
+}
+
+// PublishNewModule creates a draft module like CreateDraftModule and
+// publishes it as params.Version, which must be a semantic version. When
+// params.ParentModuleId is set the version must be higher than every
+// published version of the parent, otherwise ErrVersionExists or
+// ErrVersionNotNewer is returned before anything is created.
+func (self *MarketplaceClient) PublishNewModule(params ModuleCreate) (*string, error) {
+	ctx := context.Background()
+	if params.IdempotencyKey != "" {
+		ctx = WithIdempotencyKey(ctx, params.IdempotencyKey)
+	}
+	if _, err := ParseVersion(params.Version); err != nil {
 		return nil, err
 	}
-	var publishModuleData struct {
-		PublishDraftModuleV2 struct {
-			Id      string
-			Version struct {
-				Version string
-			}
+	if params.ParentModuleId != nil {
+		published, err := self.listModuleVersions(ctx, *params.ParentModuleId)
+		if err != nil {
+			return nil, err
+		}
+		if err := checkPublishableVersion(published, params.Version); err != nil {
+			return nil, err
 		}
 	}
-	err = mapstructure.Decode(publishRes, &publishModuleData)
+	progress, err := self.createModuleDraft(ctx, params)
 	if err != nil {
 		return nil, err
 	}
-	return &publishModuleData.PublishDraftModuleV2.Id, nil
+	res, err := PublishModule(deriveIdempotencyKey(ctx, "publish"), self, PublishModuleVariables{
+		Input: PublishDraftModuleInputV2{
+			ModuleId: progress.moduleId,
+			Version:  ModuleVersionInput{Version: params.Version},
+		},
+	})
+	if err != nil {
+		return nil, progress.fail(ctx, self, params, DRAFT_STEP_PUBLISH, err)
+	}
+	return &res.PublishDraftModuleV2.Id, nil
+}
+
+// PublishNewModuleVersion publishes params as a new version of an existing
+// module, see PublishNewModule
+func (self *MarketplaceClient) PublishNewModuleVersion(moduleId string, params ModuleCreate) (*string, error) {
+	params.ParentModuleId = &moduleId
+	return self.PublishNewModule(params)
 }
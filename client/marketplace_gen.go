@@ -0,0 +1,1182 @@
+// Code generated by phc-codegen. DO NOT EDIT.
+
+package client
+
+import (
+	"context"
+)
+
+// GetPublishedModuleDocument is the query GetPublishedModule
+const GetPublishedModuleDocument = `
+query GetPublishedModule ($id: ID!, $version: String) {
+  myModule(moduleId: $id, version: $version) {
+    title
+    description
+    version
+    source {
+      ... on AppTile {
+        id
+      }
+    }
+    iconV2 {
+      url
+      fileName
+      fileExtension
+    }
+  }
+}
+`
+
+type GetPublishedModuleVariables struct {
+	Id      string           `json:"id"`
+	Version Optional[string] `json:"version"`
+}
+
+type GetPublishedModuleResponse struct {
+	MyModule *GetPublishedModuleMyModule `json:"myModule"`
+}
+
+type GetPublishedModuleMyModule struct {
+	Title       string                            `json:"title"`
+	Description string                            `json:"description"`
+	Version     string                            `json:"version"`
+	Source      GetPublishedModuleMyModuleSource  `json:"source"`
+	IconV2      *GetPublishedModuleMyModuleIconV2 `json:"iconV2"`
+}
+
+type GetPublishedModuleMyModuleSource struct {
+	Id string `json:"id"`
+}
+
+type GetPublishedModuleMyModuleIconV2 struct {
+	Url           string `json:"url"`
+	FileName      string `json:"fileName"`
+	FileExtension string `json:"fileExtension"`
+}
+
+// GetPublishedModule runs the query GetPublishedModule
+func GetPublishedModule(ctx context.Context, client Querier, variables GetPublishedModuleVariables) (*GetPublishedModuleResponse, error) {
+	vars, err := StructToVariables(variables)
+	if err != nil {
+		return nil, err
+	}
+	res, err := client.GqlWithContext(ctx, GetPublishedModuleDocument, vars)
+	if err != nil {
+		return nil, err
+	}
+	var response GetPublishedModuleResponse
+	err = DecodeData(res, &response)
+	if err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// GetAppTileModuleVersionDocument is the query GetAppTileModuleVersion
+const GetAppTileModuleVersionDocument = `
+query GetAppTileModuleVersion ($id: ID!, $version: String) {
+  myModule(moduleId: $id, version: $version) {
+    title
+    description
+    version
+    source {
+      ... on AppTile {
+        id
+      }
+    }
+    iconV2 {
+      ... ImageFields
+    }
+    images {
+      ... ImageFields
+    }
+  }
+}
+fragment ImageFields on Image {
+  id
+  type
+  position
+  url
+  fileName
+  fileExtension
+  width
+  height
+}
+`
+
+type GetAppTileModuleVersionVariables struct {
+	Id      string           `json:"id"`
+	Version Optional[string] `json:"version"`
+}
+
+type GetAppTileModuleVersionResponse struct {
+	MyModule *GetAppTileModuleVersionMyModule `json:"myModule"`
+}
+
+type GetAppTileModuleVersionMyModule struct {
+	Title       string                                `json:"title"`
+	Description string                                `json:"description"`
+	Version     string                                `json:"version"`
+	Source      GetAppTileModuleVersionMyModuleSource `json:"source"`
+	IconV2      *ImageFields                          `json:"iconV2"`
+	Images      []ImageFields                         `json:"images"`
+}
+
+type GetAppTileModuleVersionMyModuleSource struct {
+	Id string `json:"id"`
+}
+
+type ImageFields struct {
+	Id            string    `json:"id"`
+	Type          ImageType `json:"type"`
+	Position      int       `json:"position"`
+	Url           string    `json:"url"`
+	FileName      string    `json:"fileName"`
+	FileExtension string    `json:"fileExtension"`
+	Width         *int      `json:"width"`
+	Height        *int      `json:"height"`
+}
+
+// GetAppTileModuleVersion runs the query GetAppTileModuleVersion
+func GetAppTileModuleVersion(ctx context.Context, client Querier, variables GetAppTileModuleVersionVariables) (*GetAppTileModuleVersionResponse, error) {
+	vars, err := StructToVariables(variables)
+	if err != nil {
+		return nil, err
+	}
+	res, err := client.GqlWithContext(ctx, GetAppTileModuleVersionDocument, vars)
+	if err != nil {
+		return nil, err
+	}
+	var response GetAppTileModuleVersionResponse
+	err = DecodeData(res, &response)
+	if err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// GetModuleStatusDocument is the query GetModuleStatus
+const GetModuleStatusDocument = `
+query GetModuleStatus ($id: ID!, $version: String) {
+  myModule(moduleId: $id, version: $version) {
+    id
+    version
+    status
+    statusReason
+  }
+}
+`
+
+type GetModuleStatusVariables struct {
+	Id      string           `json:"id"`
+	Version Optional[string] `json:"version"`
+}
+
+type GetModuleStatusResponse struct {
+	MyModule *GetModuleStatusMyModule `json:"myModule"`
+}
+
+type GetModuleStatusMyModule struct {
+	Id           string       `json:"id"`
+	Version      string       `json:"version"`
+	Status       ModuleStatus `json:"status"`
+	StatusReason *string      `json:"statusReason"`
+}
+
+// GetModuleStatus runs the query GetModuleStatus
+func GetModuleStatus(ctx context.Context, client Querier, variables GetModuleStatusVariables) (*GetModuleStatusResponse, error) {
+	vars, err := StructToVariables(variables)
+	if err != nil {
+		return nil, err
+	}
+	res, err := client.GqlWithContext(ctx, GetModuleStatusDocument, vars)
+	if err != nil {
+		return nil, err
+	}
+	var response GetModuleStatusResponse
+	err = DecodeData(res, &response)
+	if err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// ListModuleVersionsDocument is the query ListModuleVersions
+const ListModuleVersionsDocument = `
+query ListModuleVersions ($id: ID!) {
+  myModule(moduleId: $id) {
+    id
+    versions {
+      version
+    }
+  }
+}
+`
+
+type ListModuleVersionsVariables struct {
+	Id string `json:"id"`
+}
+
+type ListModuleVersionsResponse struct {
+	MyModule *ListModuleVersionsMyModule `json:"myModule"`
+}
+
+type ListModuleVersionsMyModule struct {
+	Id       string                               `json:"id"`
+	Versions []ListModuleVersionsMyModuleVersions `json:"versions"`
+}
+
+type ListModuleVersionsMyModuleVersions struct {
+	Version string `json:"version"`
+}
+
+// ListModuleVersions runs the query ListModuleVersions
+func ListModuleVersions(ctx context.Context, client Querier, variables ListModuleVersionsVariables) (*ListModuleVersionsResponse, error) {
+	vars, err := StructToVariables(variables)
+	if err != nil {
+		return nil, err
+	}
+	res, err := client.GqlWithContext(ctx, ListModuleVersionsDocument, vars)
+	if err != nil {
+		return nil, err
+	}
+	var response ListModuleVersionsResponse
+	err = DecodeData(res, &response)
+	if err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// ListModulesDocument is the query ListModules
+const ListModulesDocument = `
+query ListModules ($first: Int, $after: String) {
+  myModules(first: $first, after: $after) {
+    edges {
+      cursor
+      node {
+        id
+        title
+        description
+        version
+        category
+      }
+    }
+    pageInfo {
+      hasNextPage
+      endCursor
+    }
+  }
+}
+`
+
+type ListModulesVariables struct {
+	First Optional[int]    `json:"first"`
+	After Optional[string] `json:"after"`
+}
+
+type ListModulesResponse struct {
+	MyModules ListModulesMyModules `json:"myModules"`
+}
+
+type ListModulesMyModules struct {
+	Edges    []ListModulesMyModulesEdges  `json:"edges"`
+	PageInfo ListModulesMyModulesPageInfo `json:"pageInfo"`
+}
+
+type ListModulesMyModulesEdges struct {
+	Cursor string                        `json:"cursor"`
+	Node   ListModulesMyModulesEdgesNode `json:"node"`
+}
+
+type ListModulesMyModulesEdgesNode struct {
+	Id          string         `json:"id"`
+	Title       string         `json:"title"`
+	Description string         `json:"description"`
+	Version     string         `json:"version"`
+	Category    ModuleCategory `json:"category"`
+}
+
+type ListModulesMyModulesPageInfo struct {
+	HasNextPage bool    `json:"hasNextPage"`
+	EndCursor   *string `json:"endCursor"`
+}
+
+// ListModules runs the query ListModules
+func ListModules(ctx context.Context, client Querier, variables ListModulesVariables) (*ListModulesResponse, error) {
+	vars, err := StructToVariables(variables)
+	if err != nil {
+		return nil, err
+	}
+	res, err := client.GqlWithContext(ctx, ListModulesDocument, vars)
+	if err != nil {
+		return nil, err
+	}
+	var response ListModulesResponse
+	err = DecodeData(res, &response)
+	if err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// GetDraftModuleDocument is the query GetDraftModule
+const GetDraftModuleDocument = `
+query GetDraftModule ($id: ID!) {
+  myDraftModule(moduleId: $id) {
+    id
+    title
+    description
+    category
+    parentModuleId
+    source {
+      ... on AppTile {
+        id
+      }
+    }
+    iconV2 {
+      url
+      fileName
+      fileExtension
+    }
+  }
+}
+`
+
+type GetDraftModuleVariables struct {
+	Id string `json:"id"`
+}
+
+type GetDraftModuleResponse struct {
+	MyDraftModule *GetDraftModuleMyDraftModule `json:"myDraftModule"`
+}
+
+type GetDraftModuleMyDraftModule struct {
+	Id             string                             `json:"id"`
+	Title          string                             `json:"title"`
+	Description    string                             `json:"description"`
+	Category       ModuleCategory                     `json:"category"`
+	ParentModuleId *string                            `json:"parentModuleId"`
+	Source         *GetDraftModuleMyDraftModuleSource `json:"source"`
+	IconV2         *GetDraftModuleMyDraftModuleIconV2 `json:"iconV2"`
+}
+
+type GetDraftModuleMyDraftModuleSource struct {
+	Id string `json:"id"`
+}
+
+type GetDraftModuleMyDraftModuleIconV2 struct {
+	Url           string `json:"url"`
+	FileName      string `json:"fileName"`
+	FileExtension string `json:"fileExtension"`
+}
+
+// GetDraftModule runs the query GetDraftModule
+func GetDraftModule(ctx context.Context, client Querier, variables GetDraftModuleVariables) (*GetDraftModuleResponse, error) {
+	vars, err := StructToVariables(variables)
+	if err != nil {
+		return nil, err
+	}
+	res, err := client.GqlWithContext(ctx, GetDraftModuleDocument, vars)
+	if err != nil {
+		return nil, err
+	}
+	var response GetDraftModuleResponse
+	err = DecodeData(res, &response)
+	if err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// GetDraftModuleImagesDocument is the query GetDraftModuleImages
+const GetDraftModuleImagesDocument = `
+query GetDraftModuleImages ($id: ID!) {
+  myDraftModule(moduleId: $id) {
+    id
+    iconV2 {
+      ... ImageFields
+    }
+    images {
+      ... ImageFields
+    }
+  }
+}
+fragment ImageFields on Image {
+  id
+  type
+  position
+  url
+  fileName
+  fileExtension
+  width
+  height
+}
+`
+
+type GetDraftModuleImagesVariables struct {
+	Id string `json:"id"`
+}
+
+type GetDraftModuleImagesResponse struct {
+	MyDraftModule *GetDraftModuleImagesMyDraftModule `json:"myDraftModule"`
+}
+
+type GetDraftModuleImagesMyDraftModule struct {
+	Id     string        `json:"id"`
+	IconV2 *ImageFields  `json:"iconV2"`
+	Images []ImageFields `json:"images"`
+}
+
+// GetDraftModuleImages runs the query GetDraftModuleImages
+func GetDraftModuleImages(ctx context.Context, client Querier, variables GetDraftModuleImagesVariables) (*GetDraftModuleImagesResponse, error) {
+	vars, err := StructToVariables(variables)
+	if err != nil {
+		return nil, err
+	}
+	res, err := client.GqlWithContext(ctx, GetDraftModuleImagesDocument, vars)
+	if err != nil {
+		return nil, err
+	}
+	var response GetDraftModuleImagesResponse
+	err = DecodeData(res, &response)
+	if err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// ListDraftModulesDocument is the query ListDraftModules
+const ListDraftModulesDocument = `
+query ListDraftModules ($first: Int, $after: String) {
+  myDraftModules(first: $first, after: $after) {
+    edges {
+      cursor
+      node {
+        id
+        title
+        description
+        category
+      }
+    }
+    pageInfo {
+      hasNextPage
+      endCursor
+    }
+  }
+}
+`
+
+type ListDraftModulesVariables struct {
+	First Optional[int]    `json:"first"`
+	After Optional[string] `json:"after"`
+}
+
+type ListDraftModulesResponse struct {
+	MyDraftModules ListDraftModulesMyDraftModules `json:"myDraftModules"`
+}
+
+type ListDraftModulesMyDraftModules struct {
+	Edges    []ListDraftModulesMyDraftModulesEdges  `json:"edges"`
+	PageInfo ListDraftModulesMyDraftModulesPageInfo `json:"pageInfo"`
+}
+
+type ListDraftModulesMyDraftModulesEdges struct {
+	Cursor string                                  `json:"cursor"`
+	Node   ListDraftModulesMyDraftModulesEdgesNode `json:"node"`
+}
+
+type ListDraftModulesMyDraftModulesEdgesNode struct {
+	Id          string         `json:"id"`
+	Title       string         `json:"title"`
+	Description string         `json:"description"`
+	Category    ModuleCategory `json:"category"`
+}
+
+type ListDraftModulesMyDraftModulesPageInfo struct {
+	HasNextPage bool    `json:"hasNextPage"`
+	EndCursor   *string `json:"endCursor"`
+}
+
+// ListDraftModules runs the query ListDraftModules
+func ListDraftModules(ctx context.Context, client Querier, variables ListDraftModulesVariables) (*ListDraftModulesResponse, error) {
+	vars, err := StructToVariables(variables)
+	if err != nil {
+		return nil, err
+	}
+	res, err := client.GqlWithContext(ctx, ListDraftModulesDocument, vars)
+	if err != nil {
+		return nil, err
+	}
+	var response ListDraftModulesResponse
+	err = DecodeData(res, &response)
+	if err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// CreateDraftModuleDocument is the mutation CreateDraftModule
+const CreateDraftModuleDocument = `
+mutation CreateDraftModule ($input: CreateDraftModuleInput!) {
+  createDraftModule(input: $input) {
+    id
+  }
+}
+`
+
+type CreateDraftModuleVariables struct {
+	Input CreateDraftModuleInput `json:"input"`
+}
+
+type CreateDraftModuleResponse struct {
+	CreateDraftModule CreateDraftModuleCreateDraftModule `json:"createDraftModule"`
+}
+
+type CreateDraftModuleCreateDraftModule struct {
+	Id string `json:"id"`
+}
+
+// CreateDraftModule runs the mutation CreateDraftModule
+func CreateDraftModule(ctx context.Context, client Querier, variables CreateDraftModuleVariables) (*CreateDraftModuleResponse, error) {
+	vars, err := StructToVariables(variables)
+	if err != nil {
+		return nil, err
+	}
+	res, err := client.GqlWithContext(ctx, CreateDraftModuleDocument, vars)
+	if err != nil {
+		return nil, err
+	}
+	var response CreateDraftModuleResponse
+	err = DecodeData(res, &response)
+	if err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// SetAppTileDocument is the mutation SetAppTile
+const SetAppTileDocument = `
+mutation SetAppTile ($input: SetPublicAppTileDraftModuleSourceInput!) {
+  setPublicAppTileDraftModuleSource(input: $input) {
+    moduleId
+  }
+}
+`
+
+type SetAppTileVariables struct {
+	Input SetPublicAppTileDraftModuleSourceInput `json:"input"`
+}
+
+type SetAppTileResponse struct {
+	SetPublicAppTileDraftModuleSource SetAppTileSetPublicAppTileDraftModuleSource `json:"setPublicAppTileDraftModuleSource"`
+}
+
+type SetAppTileSetPublicAppTileDraftModuleSource struct {
+	ModuleId string `json:"moduleId"`
+}
+
+// SetAppTile runs the mutation SetAppTile
+func SetAppTile(ctx context.Context, client Querier, variables SetAppTileVariables) (*SetAppTileResponse, error) {
+	vars, err := StructToVariables(variables)
+	if err != nil {
+		return nil, err
+	}
+	res, err := client.GqlWithContext(ctx, SetAppTileDocument, vars)
+	if err != nil {
+		return nil, err
+	}
+	var response SetAppTileResponse
+	err = DecodeData(res, &response)
+	if err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// SetSurveyDocument is the mutation SetSurvey
+const SetSurveyDocument = `
+mutation SetSurvey ($input: SetSurveyDraftModuleSourceInput!) {
+  setSurveyDraftModuleSource(input: $input) {
+    moduleId
+  }
+}
+`
+
+type SetSurveyVariables struct {
+	Input SetSurveyDraftModuleSourceInput `json:"input"`
+}
+
+type SetSurveyResponse struct {
+	SetSurveyDraftModuleSource SetSurveySetSurveyDraftModuleSource `json:"setSurveyDraftModuleSource"`
+}
+
+type SetSurveySetSurveyDraftModuleSource struct {
+	ModuleId string `json:"moduleId"`
+}
+
+// SetSurvey runs the mutation SetSurvey
+func SetSurvey(ctx context.Context, client Querier, variables SetSurveyVariables) (*SetSurveyResponse, error) {
+	vars, err := StructToVariables(variables)
+	if err != nil {
+		return nil, err
+	}
+	res, err := client.GqlWithContext(ctx, SetSurveyDocument, vars)
+	if err != nil {
+		return nil, err
+	}
+	var response SetSurveyResponse
+	err = DecodeData(res, &response)
+	if err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// SetMessageDocument is the mutation SetMessage
+const SetMessageDocument = `
+mutation SetMessage ($input: SetMessageDraftModuleSourceInput!) {
+  setMessageDraftModuleSource(input: $input) {
+    moduleId
+  }
+}
+`
+
+type SetMessageVariables struct {
+	Input SetMessageDraftModuleSourceInput `json:"input"`
+}
+
+type SetMessageResponse struct {
+	SetMessageDraftModuleSource SetMessageSetMessageDraftModuleSource `json:"setMessageDraftModuleSource"`
+}
+
+type SetMessageSetMessageDraftModuleSource struct {
+	ModuleId string `json:"moduleId"`
+}
+
+// SetMessage runs the mutation SetMessage
+func SetMessage(ctx context.Context, client Querier, variables SetMessageVariables) (*SetMessageResponse, error) {
+	vars, err := StructToVariables(variables)
+	if err != nil {
+		return nil, err
+	}
+	res, err := client.GqlWithContext(ctx, SetMessageDocument, vars)
+	if err != nil {
+		return nil, err
+	}
+	var response SetMessageResponse
+	err = DecodeData(res, &response)
+	if err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// PublishModuleDocument is the mutation PublishModule
+const PublishModuleDocument = `
+mutation PublishModule ($input: PublishDraftModuleInputV2!) {
+  publishDraftModuleV2(input: $input) {
+    id
+    version {
+      version
+    }
+  }
+}
+`
+
+type PublishModuleVariables struct {
+	Input PublishDraftModuleInputV2 `json:"input"`
+}
+
+type PublishModuleResponse struct {
+	PublishDraftModuleV2 PublishModulePublishDraftModuleV2 `json:"publishDraftModuleV2"`
+}
+
+type PublishModulePublishDraftModuleV2 struct {
+	Id      string                                   `json:"id"`
+	Version PublishModulePublishDraftModuleV2Version `json:"version"`
+}
+
+type PublishModulePublishDraftModuleV2Version struct {
+	Version string `json:"version"`
+}
+
+// PublishModule runs the mutation PublishModule
+func PublishModule(ctx context.Context, client Querier, variables PublishModuleVariables) (*PublishModuleResponse, error) {
+	vars, err := StructToVariables(variables)
+	if err != nil {
+		return nil, err
+	}
+	res, err := client.GqlWithContext(ctx, PublishModuleDocument, vars)
+	if err != nil {
+		return nil, err
+	}
+	var response PublishModuleResponse
+	err = DecodeData(res, &response)
+	if err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// StartImageUploadDocument is the mutation StartImageUpload
+const StartImageUploadDocument = `
+mutation StartImageUpload ($input: StartUploadInput!) {
+  startUpload(input: $input) {
+    id
+    url
+    fields
+  }
+}
+`
+
+type StartImageUploadVariables struct {
+	Input StartUploadInput `json:"input"`
+}
+
+type StartImageUploadResponse struct {
+	StartUpload StartImageUploadStartUpload `json:"startUpload"`
+}
+
+type StartImageUploadStartUpload struct {
+	Id     string            `json:"id"`
+	Url    string            `json:"url"`
+	Fields map[string]string `json:"fields"`
+}
+
+// StartImageUpload runs the mutation StartImageUpload
+func StartImageUpload(ctx context.Context, client Querier, variables StartImageUploadVariables) (*StartImageUploadResponse, error) {
+	vars, err := StructToVariables(variables)
+	if err != nil {
+		return nil, err
+	}
+	res, err := client.GqlWithContext(ctx, StartImageUploadDocument, vars)
+	if err != nil {
+		return nil, err
+	}
+	var response StartImageUploadResponse
+	err = DecodeData(res, &response)
+	if err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// FinalizeImageUploadDocument is the mutation FinalizeImageUpload
+const FinalizeImageUploadDocument = `
+mutation FinalizeImageUpload ($input: FinalizeUploadInput!) {
+  finalizeUpload(input: $input) {
+    moduleId
+  }
+}
+`
+
+type FinalizeImageUploadVariables struct {
+	Input FinalizeUploadInput `json:"input"`
+}
+
+type FinalizeImageUploadResponse struct {
+	FinalizeUpload FinalizeImageUploadFinalizeUpload `json:"finalizeUpload"`
+}
+
+type FinalizeImageUploadFinalizeUpload struct {
+	ModuleId string `json:"moduleId"`
+}
+
+// FinalizeImageUpload runs the mutation FinalizeImageUpload
+func FinalizeImageUpload(ctx context.Context, client Querier, variables FinalizeImageUploadVariables) (*FinalizeImageUploadResponse, error) {
+	vars, err := StructToVariables(variables)
+	if err != nil {
+		return nil, err
+	}
+	res, err := client.GqlWithContext(ctx, FinalizeImageUploadDocument, vars)
+	if err != nil {
+		return nil, err
+	}
+	var response FinalizeImageUploadResponse
+	err = DecodeData(res, &response)
+	if err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// FinalizeModuleImageUploadDocument is the mutation FinalizeModuleImageUpload
+const FinalizeModuleImageUploadDocument = `
+mutation FinalizeModuleImageUpload ($input: FinalizeUploadInput!) {
+  finalizeUpload(input: $input) {
+    moduleId
+    image {
+      ... ImageFields
+    }
+  }
+}
+fragment ImageFields on Image {
+  id
+  type
+  position
+  url
+  fileName
+  fileExtension
+  width
+  height
+}
+`
+
+type FinalizeModuleImageUploadVariables struct {
+	Input FinalizeUploadInput `json:"input"`
+}
+
+type FinalizeModuleImageUploadResponse struct {
+	FinalizeUpload FinalizeModuleImageUploadFinalizeUpload `json:"finalizeUpload"`
+}
+
+type FinalizeModuleImageUploadFinalizeUpload struct {
+	ModuleId string       `json:"moduleId"`
+	Image    *ImageFields `json:"image"`
+}
+
+// FinalizeModuleImageUpload runs the mutation FinalizeModuleImageUpload
+func FinalizeModuleImageUpload(ctx context.Context, client Querier, variables FinalizeModuleImageUploadVariables) (*FinalizeModuleImageUploadResponse, error) {
+	vars, err := StructToVariables(variables)
+	if err != nil {
+		return nil, err
+	}
+	res, err := client.GqlWithContext(ctx, FinalizeModuleImageUploadDocument, vars)
+	if err != nil {
+		return nil, err
+	}
+	var response FinalizeModuleImageUploadResponse
+	err = DecodeData(res, &response)
+	if err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// ReorderDraftModuleImagesDocument is the mutation ReorderDraftModuleImages
+const ReorderDraftModuleImagesDocument = `
+mutation ReorderDraftModuleImages ($input: ReorderDraftModuleImagesInput!) {
+  reorderDraftModuleImages(input: $input) {
+    id
+    images {
+      ... ImageFields
+    }
+  }
+}
+fragment ImageFields on Image {
+  id
+  type
+  position
+  url
+  fileName
+  fileExtension
+  width
+  height
+}
+`
+
+type ReorderDraftModuleImagesVariables struct {
+	Input ReorderDraftModuleImagesInput `json:"input"`
+}
+
+type ReorderDraftModuleImagesResponse struct {
+	ReorderDraftModuleImages ReorderDraftModuleImagesReorderDraftModuleImages `json:"reorderDraftModuleImages"`
+}
+
+type ReorderDraftModuleImagesReorderDraftModuleImages struct {
+	Id     string        `json:"id"`
+	Images []ImageFields `json:"images"`
+}
+
+// ReorderDraftModuleImages runs the mutation ReorderDraftModuleImages
+func ReorderDraftModuleImages(ctx context.Context, client Querier, variables ReorderDraftModuleImagesVariables) (*ReorderDraftModuleImagesResponse, error) {
+	vars, err := StructToVariables(variables)
+	if err != nil {
+		return nil, err
+	}
+	res, err := client.GqlWithContext(ctx, ReorderDraftModuleImagesDocument, vars)
+	if err != nil {
+		return nil, err
+	}
+	var response ReorderDraftModuleImagesResponse
+	err = DecodeData(res, &response)
+	if err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// RemoveDraftModuleImageDocument is the mutation RemoveDraftModuleImage
+const RemoveDraftModuleImageDocument = `
+mutation RemoveDraftModuleImage ($input: RemoveDraftModuleImageInput!) {
+  removeDraftModuleImage(input: $input) {
+    id
+    images {
+      ... ImageFields
+    }
+  }
+}
+fragment ImageFields on Image {
+  id
+  type
+  position
+  url
+  fileName
+  fileExtension
+  width
+  height
+}
+`
+
+type RemoveDraftModuleImageVariables struct {
+	Input RemoveDraftModuleImageInput `json:"input"`
+}
+
+type RemoveDraftModuleImageResponse struct {
+	RemoveDraftModuleImage RemoveDraftModuleImageRemoveDraftModuleImage `json:"removeDraftModuleImage"`
+}
+
+type RemoveDraftModuleImageRemoveDraftModuleImage struct {
+	Id     string        `json:"id"`
+	Images []ImageFields `json:"images"`
+}
+
+// RemoveDraftModuleImage runs the mutation RemoveDraftModuleImage
+func RemoveDraftModuleImage(ctx context.Context, client Querier, variables RemoveDraftModuleImageVariables) (*RemoveDraftModuleImageResponse, error) {
+	vars, err := StructToVariables(variables)
+	if err != nil {
+		return nil, err
+	}
+	res, err := client.GqlWithContext(ctx, RemoveDraftModuleImageDocument, vars)
+	if err != nil {
+		return nil, err
+	}
+	var response RemoveDraftModuleImageResponse
+	err = DecodeData(res, &response)
+	if err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// UpdateDraftModuleDocument is the mutation UpdateDraftModule
+const UpdateDraftModuleDocument = `
+mutation UpdateDraftModule ($input: UpdateDraftModuleInput!) {
+  updateDraftModule(input: $input) {
+    id
+  }
+}
+`
+
+type UpdateDraftModuleVariables struct {
+	Input UpdateDraftModuleInput `json:"input"`
+}
+
+type UpdateDraftModuleResponse struct {
+	UpdateDraftModule UpdateDraftModuleUpdateDraftModule `json:"updateDraftModule"`
+}
+
+type UpdateDraftModuleUpdateDraftModule struct {
+	Id string `json:"id"`
+}
+
+// UpdateDraftModule runs the mutation UpdateDraftModule
+func UpdateDraftModule(ctx context.Context, client Querier, variables UpdateDraftModuleVariables) (*UpdateDraftModuleResponse, error) {
+	vars, err := StructToVariables(variables)
+	if err != nil {
+		return nil, err
+	}
+	res, err := client.GqlWithContext(ctx, UpdateDraftModuleDocument, vars)
+	if err != nil {
+		return nil, err
+	}
+	var response UpdateDraftModuleResponse
+	err = DecodeData(res, &response)
+	if err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// DeleteDraftModuleDocument is the mutation DeleteDraftModule
+const DeleteDraftModuleDocument = `
+mutation DeleteDraftModule ($input: DeleteDraftModuleInput!) {
+  deleteDraftModule(input: $input) {
+    moduleId
+  }
+}
+`
+
+type DeleteDraftModuleVariables struct {
+	Input DeleteDraftModuleInput `json:"input"`
+}
+
+type DeleteDraftModuleResponse struct {
+	DeleteDraftModule DeleteDraftModuleDeleteDraftModule `json:"deleteDraftModule"`
+}
+
+type DeleteDraftModuleDeleteDraftModule struct {
+	ModuleId string `json:"moduleId"`
+}
+
+// DeleteDraftModule runs the mutation DeleteDraftModule
+func DeleteDraftModule(ctx context.Context, client Querier, variables DeleteDraftModuleVariables) (*DeleteDraftModuleResponse, error) {
+	vars, err := StructToVariables(variables)
+	if err != nil {
+		return nil, err
+	}
+	res, err := client.GqlWithContext(ctx, DeleteDraftModuleDocument, vars)
+	if err != nil {
+		return nil, err
+	}
+	var response DeleteDraftModuleResponse
+	err = DecodeData(res, &response)
+	if err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// DeleteModuleDocument is the mutation DeleteModule
+const DeleteModuleDocument = `
+mutation DeleteModule ($input: DeleteModuleInput!) {
+  deleteModule(input: $input) {
+    moduleId
+  }
+}
+`
+
+type DeleteModuleVariables struct {
+	Input DeleteModuleInput `json:"input"`
+}
+
+type DeleteModuleResponse struct {
+	DeleteModule DeleteModuleDeleteModule `json:"deleteModule"`
+}
+
+type DeleteModuleDeleteModule struct {
+	ModuleId string `json:"moduleId"`
+}
+
+// DeleteModule runs the mutation DeleteModule
+func DeleteModule(ctx context.Context, client Querier, variables DeleteModuleVariables) (*DeleteModuleResponse, error) {
+	vars, err := StructToVariables(variables)
+	if err != nil {
+		return nil, err
+	}
+	res, err := client.GqlWithContext(ctx, DeleteModuleDocument, vars)
+	if err != nil {
+		return nil, err
+	}
+	var response DeleteModuleResponse
+	err = DecodeData(res, &response)
+	if err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+type CreateDraftModuleInput struct {
+	Title          string           `json:"title"`
+	Description    string           `json:"description"`
+	ParentModuleId Optional[string] `json:"parentModuleId"`
+	Category       ModuleCategory   `json:"category"`
+}
+
+type DeleteDraftModuleInput struct {
+	ModuleId string `json:"moduleId"`
+}
+
+type DeleteModuleInput struct {
+	ModuleId string `json:"moduleId"`
+}
+
+type FinalizeUploadInput struct {
+	Id       string    `json:"id"`
+	ModuleId string    `json:"moduleId"`
+	Type     ImageType `json:"type"`
+}
+
+type MessageSourceInput struct {
+	Id string `json:"id"`
+}
+
+type ModuleVersionInput struct {
+	Version string `json:"version"`
+}
+
+type PublicAppTileSourceInput struct {
+	Id string `json:"id"`
+}
+
+type PublishDraftModuleInputV2 struct {
+	ModuleId string             `json:"moduleId"`
+	Version  ModuleVersionInput `json:"version"`
+}
+
+type RemoveDraftModuleImageInput struct {
+	ModuleId string `json:"moduleId"`
+	ImageId  string `json:"imageId"`
+}
+
+type ReorderDraftModuleImagesInput struct {
+	ModuleId string    `json:"moduleId"`
+	Type     ImageType `json:"type"`
+	ImageIds []string  `json:"imageIds"`
+}
+
+type SetMessageDraftModuleSourceInput struct {
+	ModuleId   string             `json:"moduleId"`
+	SourceInfo MessageSourceInput `json:"sourceInfo"`
+}
+
+type SetPublicAppTileDraftModuleSourceInput struct {
+	ModuleId   string                   `json:"moduleId"`
+	SourceInfo PublicAppTileSourceInput `json:"sourceInfo"`
+}
+
+type SetSurveyDraftModuleSourceInput struct {
+	ModuleId   string            `json:"moduleId"`
+	SourceInfo SurveySourceInput `json:"sourceInfo"`
+}
+
+type StartUploadInput struct {
+	FileName string `json:"fileName"`
+}
+
+type SurveySourceInput struct {
+	Id string `json:"id"`
+}
+
+type UpdateDraftModuleInput struct {
+	ModuleId    string           `json:"moduleId"`
+	Title       Optional[string] `json:"title"`
+	Description Optional[string] `json:"description"`
+}
+
+type ImageType string
+
+const (
+	ImageTypeIcon       ImageType = "ICON"
+	ImageTypeScreenshot ImageType = "SCREENSHOT"
+	ImageTypeBanner     ImageType = "BANNER"
+)
+
+type ModuleCategory string
+
+const (
+	ModuleCategoryAppTile ModuleCategory = "APP_TILE"
+	ModuleCategorySurvey  ModuleCategory = "SURVEY"
+	ModuleCategoryMessage ModuleCategory = "MESSAGE"
+)
+
+type ModuleStatus string
+
+const (
+	ModuleStatusProcessing ModuleStatus = "PROCESSING"
+	ModuleStatusInReview   ModuleStatus = "IN_REVIEW"
+	ModuleStatusPublished  ModuleStatus = "PUBLISHED"
+	ModuleStatusRejected   ModuleStatus = "REJECTED"
+	ModuleStatusFailed     ModuleStatus = "FAILED"
+)
@@ -1,6 +1,7 @@
 package client
 
 import (
+	"context"
 	"testing"
 )
 
@@ -44,3 +45,101 @@ func TestGetAppTileModule(t *testing.T) {
 		t.Fatal("Did not get back currect response", response)
 	}
 }
+
+func TestListModules(t *testing.T) {
+	mockClient := MockClient{
+		response: &map[string]interface{}{
+			"myModules": map[string]interface{}{
+				"edges": []interface{}{
+					map[string]interface{}{"cursor": "1", "node": map[string]interface{}{"id": "module-1", "title": "first", "category": "APP_TILE"}},
+					map[string]interface{}{"cursor": "2", "node": map[string]interface{}{"id": "module-2", "title": "second", "category": "APP_TILE"}},
+				},
+				"pageInfo": map[string]interface{}{"hasNextPage": false, "endCursor": "2"},
+			},
+		},
+	}
+	client := MarketplaceClient{client: &mockClient}
+	modules, err := client.ListModules(PageSize(10)).All(context.Background())
+	if err != nil {
+		t.Fatal("Unexpected error", err)
+	}
+	if len(modules) != 2 || modules[1].Id != "module-2" || modules[1].Category != ModuleCategoryAppTile {
+		t.Fatal("Did not list the modules", modules)
+	}
+	if mockClient.operations[0] != LIST_MODULES || mockClient.variables[0][PAGE_SIZE_VARIABLE] != 10 {
+		t.Fatal("Did not request a page of modules", mockClient.operations, mockClient.variables)
+	}
+}
+
+func TestGetDraftModule(t *testing.T) {
+	mockClient := MockClient{
+		response: &map[string]interface{}{
+			"myDraftModule": map[string]interface{}{
+				"id":             "draft",
+				"title":          "draft title",
+				"category":       "APP_TILE",
+				"parentModuleId": "parent",
+				"source":         map[string]interface{}{"id": "tile"},
+			},
+		},
+	}
+	client := MarketplaceClient{client: &mockClient}
+	draft, err := client.GetDraftModule("draft")
+	if err != nil {
+		t.Fatal("Unexpected error", err)
+	}
+	if draft == nil || draft.Title != "draft title" || *draft.ParentModuleId != "parent" || draft.Source.Id != "tile" {
+		t.Fatal("Did not decode the draft", draft)
+	}
+	if mockClient.variables[0]["id"] != "draft" {
+		t.Fatal("Did not send the id", mockClient.variables)
+	}
+}
+
+func TestUpdateDraftModule(t *testing.T) {
+	mockClient := MockClient{response: &map[string]interface{}{}}
+	client := MarketplaceClient{client: &mockClient}
+	err := client.UpdateDraftModule("draft", DraftModuleUpdate{
+		Title:  Some("new title"),
+		Source: &AppTileSource{Id: "tile"},
+	})
+	if err != nil {
+		t.Fatal("Unexpected error", err)
+	}
+	if len(mockClient.operations) != 2 || mockClient.operations[0] != UPDATE_DRAFT_MODULE || mockClient.operations[1] != SET_APP_TILE {
+		t.Fatal("Expected an update and a source change", mockClient.operations)
+	}
+	input := mockClient.variables[0]["input"].(map[string]interface{})
+	if input["moduleId"] != "draft" || input["title"] != "new title" {
+		t.Fatal("Did not send the update", input)
+	}
+	if _, ok := input["description"]; ok {
+		t.Fatal("Unset fields should not be sent", input)
+	}
+
+	mockClient = MockClient{response: &map[string]interface{}{}}
+	client = MarketplaceClient{client: &mockClient}
+	if err := client.UpdateDraftModule("draft", DraftModuleUpdate{Source: &AppTileSource{Id: "tile"}}); err != nil {
+		t.Fatal("Unexpected error", err)
+	}
+	if len(mockClient.operations) != 1 || mockClient.operations[0] != SET_APP_TILE {
+		t.Fatal("Only the source should be changed", mockClient.operations)
+	}
+}
+
+func TestDeleteModules(t *testing.T) {
+	mockClient := MockClient{response: &map[string]interface{}{}}
+	client := MarketplaceClient{client: &mockClient}
+	if err := client.DeleteDraftModule("draft"); err != nil {
+		t.Fatal("Unexpected error", err)
+	}
+	if err := client.DeleteModule("module"); err != nil {
+		t.Fatal("Unexpected error", err)
+	}
+	if mockClient.operations[0] != DELETE_DRAFT_MODULE || mockClient.operations[1] != DELETE_MODULE {
+		t.Fatal("Unexpected operations", mockClient.operations)
+	}
+	if mockClient.variables[1]["input"].(map[string]interface{})["moduleId"] != "module" {
+		t.Fatal("Did not send the module id", mockClient.variables)
+	}
+}
@@ -0,0 +1,172 @@
+package client
+
+import (
+	"encoding/json"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Error classes reported in Invocation.ErrorClass
+const (
+	ERROR_CLASS_INVOKE   = "invoke"
+	ERROR_CLASS_FUNCTION = "function"
+	ERROR_CLASS_HTTP     = "http"
+	ERROR_CLASS_GRAPHQL  = "graphql"
+	ERROR_CLASS_DECODE   = "decode"
+)
+
+type MetricLabels struct {
+	FunctionName string
+	Path         string
+	Operation    string
+}
+
+// Invocation describes a single lambda invocation made by the client
+type Invocation struct {
+	Labels        MetricLabels
+	Duration      time.Duration
+	RequestBytes  int
+	ResponseBytes int
+	Retries       int
+	// ErrorClass is empty for successful invocations
+	ErrorClass string
+}
+
+// MetricsRecorder receives every invocation made by a LambdaClient. Recorders
+// are called synchronously so they should not block.
+type MetricsRecorder interface {
+	RecordInvocation(Invocation)
+}
+
+type OperationStats struct {
+	Labels        MetricLabels
+	Invocations   int
+	Errors        map[string]int
+	Retries       int
+	TotalLatency  time.Duration
+	MaxLatency    time.Duration
+	RequestBytes  int
+	ResponseBytes int
+}
+
+func (s OperationStats) AverageLatency() time.Duration {
+	if s.Invocations == 0 {
+		return 0
+	}
+	return s.TotalLatency / time.Duration(s.Invocations)
+}
+
+// Stats is an in-memory MetricsRecorder for environments without a metrics
+// backend. Every LambdaClient keeps one, see LambdaClient.Stats.
+type Stats struct {
+	mutex      sync.Mutex
+	operations map[MetricLabels]*OperationStats
+}
+
+func (s *Stats) RecordInvocation(invocation Invocation) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.operations == nil {
+		s.operations = map[MetricLabels]*OperationStats{}
+	}
+	stats, ok := s.operations[invocation.Labels]
+	if !ok {
+		stats = &OperationStats{Labels: invocation.Labels, Errors: map[string]int{}}
+		s.operations[invocation.Labels] = stats
+	}
+	stats.Invocations++
+	if invocation.ErrorClass != "" {
+		stats.Errors[invocation.ErrorClass]++
+	}
+	stats.Retries += invocation.Retries
+	stats.TotalLatency += invocation.Duration
+	if invocation.Duration > stats.MaxLatency {
+		stats.MaxLatency = invocation.Duration
+	}
+	stats.RequestBytes += invocation.RequestBytes
+	stats.ResponseBytes += invocation.ResponseBytes
+}
+
+// Snapshot returns a copy of the collected stats sorted by function name,
+// path and operation
+func (s *Stats) Snapshot() []OperationStats {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	snapshot := make([]OperationStats, 0, len(s.operations))
+	for _, stats := range s.operations {
+		copied := *stats
+		copied.Errors = make(map[string]int, len(stats.Errors))
+		for class, count := range stats.Errors {
+			copied.Errors[class] = count
+		}
+		snapshot = append(snapshot, copied)
+	}
+	sort.Slice(snapshot, func(i, j int) bool {
+		a, b := snapshot[i].Labels, snapshot[j].Labels
+		if a.FunctionName != b.FunctionName {
+			return a.FunctionName < b.FunctionName
+		}
+		if a.Path != b.Path {
+			return a.Path < b.Path
+		}
+		return a.Operation < b.Operation
+	})
+	return snapshot
+}
+
+// classifyResponse inspects a lambda response payload and returns the class of
+// error it represents, if any. GraphQL errors are only checked for GraphQL
+// operations since Do may be used for plain REST calls.
+func classifyResponse(raw []byte, functionError *string, graphql bool) string {
+	if functionError != nil {
+		return ERROR_CLASS_FUNCTION
+	}
+	var payload responsePayload
+	if err := json.Unmarshal(raw, &payload); err != nil {
+		return ERROR_CLASS_DECODE
+	}
+	if payload.StatusCode >= 400 {
+		return ERROR_CLASS_HTTP
+	}
+	if !graphql {
+		return ""
+	}
+	var body responseBody
+	if err := json.Unmarshal([]byte(payload.Body), &body); err != nil {
+		return ERROR_CLASS_DECODE
+	}
+	if len(body.Errors) > 0 {
+		return ERROR_CLASS_GRAPHQL
+	}
+	return ""
+}
+
+// operationLabel returns the name of the first named operation in the
+// document, falling back to its kind for anonymous operations
+func operationLabel(document string) string {
+	operations, err := parseOperations(document)
+	if err != nil || len(operations) == 0 {
+		return ""
+	}
+	if operations[0].Name != "" {
+		return operations[0].Name
+	}
+	return operations[0].Kind
+}
+
+// operationFromBody returns the operation label for a request made through Do
+// when its body is a GraphQL request
+func operationFromBody(body []byte) string {
+	var request struct {
+		Query         string `json:"query"`
+		OperationName string `json:"operationName"`
+	}
+	if err := json.Unmarshal(body, &request); err != nil {
+		return ""
+	}
+	if request.OperationName != "" {
+		return request.OperationName
+	}
+	return operationLabel(request.Query)
+}
@@ -0,0 +1,99 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws/retry"
+	"github.com/aws/aws-sdk-go-v2/service/lambda"
+)
+
+type RecordingMetrics struct {
+	invocations []Invocation
+}
+
+func (m *RecordingMetrics) RecordInvocation(invocation Invocation) {
+	m.invocations = append(m.invocations, invocation)
+}
+
+func TestGqlRecordsMetrics(t *testing.T) {
+	mock := MockInvoker{
+		response: &lambda.InvokeOutput{
+			Payload: []byte("{ \"body\": \"{ \\\"data\\\": { \\\"result\\\": true }}\"}"),
+		},
+	}
+	recorder := RecordingMetrics{}
+	client := LambdaClient{invoker: &mock}
+	WithMetrics(&recorder)(&client)
+
+	_, err := client.Gql("some_lambda:status/some/path", MOCK_MUTATION, map[string]interface{}{"var": "value"})
+	if err != nil {
+		t.Fatal("Unexpected test Error", err)
+	}
+	mock.response = &lambda.InvokeOutput{
+		Payload: []byte("{ \"body\": \"{\\\"errors\\\": [{ \\\"message\\\": \\\"error message\\\"}] }\" }"),
+	}
+	_, err = client.Gql("some_lambda:status/some/path", MOCK_MUTATION, map[string]interface{}{"var": "value"})
+	if err == nil {
+		t.Fatal("Should have returned error value")
+	}
+
+	if len(recorder.invocations) != 2 {
+		t.Fatal("Expected every invocation to be recorded", recorder.invocations)
+	}
+	labels := recorder.invocations[0].Labels
+	if labels.FunctionName != "some_lambda:status" || labels.Path != "/some/path" || labels.Operation != "MockMutation" {
+		t.Fatal("Did not record the right labels", labels)
+	}
+	if recorder.invocations[0].RequestBytes == 0 || recorder.invocations[0].ResponseBytes == 0 {
+		t.Fatal("Did not record payload sizes", recorder.invocations[0])
+	}
+
+	stats := client.Stats()
+	if len(stats) != 1 {
+		t.Fatal("Expected a single operation in stats", stats)
+	}
+	if stats[0].Invocations != 2 || stats[0].Errors[ERROR_CLASS_GRAPHQL] != 1 {
+		t.Fatal("Did not aggregate stats", stats[0])
+	}
+}
+
+func TestGqlRecordsRetriesOfFailedInvocations(t *testing.T) {
+	mock := MockInvoker{
+		err: fmt.Errorf("operation error Lambda: Invoke, %w", &retry.MaxAttemptsError{Attempt: 3, Err: errors.New("throttled")}),
+	}
+	recorder := RecordingMetrics{}
+	client := LambdaClient{invoker: &mock}
+	WithMetrics(&recorder)(&client)
+
+	_, err := client.Gql("some_lambda:status/some/path", MOCK_MUTATION, map[string]interface{}{"var": "value"})
+	if err == nil {
+		t.Fatal("Should have returned error value")
+	}
+	if len(recorder.invocations) != 1 {
+		t.Fatal("Expected the invocation to be recorded", recorder.invocations)
+	}
+	if recorder.invocations[0].ErrorClass != ERROR_CLASS_INVOKE || recorder.invocations[0].Retries != 2 {
+		t.Fatal("Did not record the retries of the failed invocation", recorder.invocations[0])
+	}
+}
+
+func TestClassifyResponse(t *testing.T) {
+	functionError := "Unhandled"
+	cases := []struct {
+		raw           string
+		functionError *string
+		expected      string
+	}{
+		{"{ \"body\": \"{}\", \"statusCode\": 200 }", nil, ""},
+		{"{ \"body\": \"{}\" }", &functionError, ERROR_CLASS_FUNCTION},
+		{"{ \"body\": \"{}\", \"statusCode\": 502 }", nil, ERROR_CLASS_HTTP},
+		{"not json", nil, ERROR_CLASS_DECODE},
+	}
+	for _, c := range cases {
+		if class := classifyResponse([]byte(c.raw), c.functionError, true); class != c.expected {
+			t.Fatal("Did not classify response right", c.raw, class)
+		}
+	}
+}
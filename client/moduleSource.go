@@ -0,0 +1,217 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/lifeomic/phc-sdk-go/graphql"
+)
+
+// ModuleSource is the content a marketplace module publishes, such as an app
+// tile or a survey. Each module category has its own source type and its own
+// mutation to set the source of a draft module.
+type ModuleSource interface {
+	Category() ModuleCategory
+	// SetDraftSource sets the source of the draft module
+	SetDraftSource(ctx context.Context, client Querier, moduleId string) error
+}
+
+// ModuleSourceType describes how a member of the `ModuleSource` union is
+// selected and decoded. Types for categories this package doesn't know about
+// can be added with MarketplaceClient.RegisterModuleSource.
+type ModuleSourceType struct {
+	// Typename is the name of the GraphQL type in the union, e.g. `AppTile`
+	Typename string
+	// Selections are the fields selected on the type
+	Selections []graphql.Selection
+	// New returns an empty source the selected fields are decoded into
+	New func() ModuleSource
+}
+
+// APP_TILE_MODULE_SOURCE is the only source type registered by default
+var APP_TILE_MODULE_SOURCE = ModuleSourceType{
+	Typename:   "AppTile",
+	Selections: graphql.Fields("id"),
+	New:        func() ModuleSource { return &AppTileSource{} },
+}
+
+// The survey and message source types are not confirmed against the deployed
+// schema, register them with RegisterModuleSource to decode those sources
+var (
+	SURVEY_MODULE_SOURCE = ModuleSourceType{
+		Typename:   "Survey",
+		Selections: graphql.Fields("id"),
+		New:        func() ModuleSource { return &SurveySource{} },
+	}
+	MESSAGE_MODULE_SOURCE = ModuleSourceType{
+		Typename:   "Message",
+		Selections: graphql.Fields("id"),
+		New:        func() ModuleSource { return &MessageSource{} },
+	}
+)
+
+var defaultModuleSources = map[string]ModuleSourceType{
+	APP_TILE_MODULE_SOURCE.Typename: APP_TILE_MODULE_SOURCE,
+}
+
+// moduleSourceRegistry holds the source types registered on a LambdaClient,
+// every MarketplaceClient it returns shares them
+type moduleSourceRegistry struct {
+	mutex sync.RWMutex
+	// types is replaced rather than changed, nil until a type is registered
+	types map[string]ModuleSourceType
+}
+
+func (r *moduleSourceRegistry) register(sourceType ModuleSourceType) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	current := r.types
+	if current == nil {
+		current = defaultModuleSources
+	}
+	types := make(map[string]ModuleSourceType, len(current)+1)
+	for typename, existing := range current {
+		types[typename] = existing
+	}
+	types[sourceType.Typename] = sourceType
+	r.types = types
+}
+
+func (r *moduleSourceRegistry) sourceTypes() map[string]ModuleSourceType {
+	if r == nil {
+		return defaultModuleSources
+	}
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	if r.types == nil {
+		return defaultModuleSources
+	}
+	return r.types
+}
+
+// RegisterModuleSource adds or replaces the source type for its typename. It
+// is registered on the LambdaClient the client was returned by, so every
+// MarketplaceClient of that LambdaClient decodes it.
+func (self *MarketplaceClient) RegisterModuleSource(sourceType ModuleSourceType) {
+	if self.moduleSources == nil {
+		self.moduleSources = &moduleSourceRegistry{}
+	}
+	self.moduleSources.register(sourceType)
+}
+
+func (self *MarketplaceClient) sourceTypes() map[string]ModuleSourceType {
+	return self.moduleSources.sourceTypes()
+}
+
+func (self *MarketplaceClient) registeredModuleSources() []ModuleSourceType {
+	types := make([]ModuleSourceType, 0, len(self.sourceTypes()))
+	for _, sourceType := range self.sourceTypes() {
+		types = append(types, sourceType)
+	}
+	sort.Slice(types, func(i, j int) bool {
+		return types[i].Typename < types[j].Typename
+	})
+	return types
+}
+
+// moduleSourceSelection selects the `__typename` and the fields of every
+// registered source type
+func (self *MarketplaceClient) moduleSourceSelection() *graphql.FieldSelection {
+	source := graphql.Field("source", graphql.Field("__typename"))
+	for _, sourceType := range self.registeredModuleSources() {
+		source.Select(graphql.On(sourceType.Typename, sourceType.Selections...))
+	}
+	return source
+}
+
+// DecodeModuleSource decodes a `source` selected with its `__typename`. Types
+// that are not registered are returned as an UnknownModuleSource.
+func (self *MarketplaceClient) DecodeModuleSource(source map[string]interface{}) (ModuleSource, error) {
+	if source == nil {
+		return nil, nil
+	}
+	typename, _ := source["__typename"].(string)
+	if typename == "" {
+		return nil, fmt.Errorf("module source has no __typename")
+	}
+	sourceType, ok := self.sourceTypes()[typename]
+	if !ok {
+		return &UnknownModuleSource{Typename: typename, Fields: source}, nil
+	}
+	decoded := sourceType.New()
+	if err := DecodeData(&source, decoded); err != nil {
+		return nil, fmt.Errorf("failed to decode %s source: %w", typename, err)
+	}
+	return decoded, nil
+}
+
+type AppTileSource struct {
+	Id string `json:"id"`
+}
+
+func (s *AppTileSource) Category() ModuleCategory {
+	return ModuleCategoryAppTile
+}
+
+func (s *AppTileSource) SetDraftSource(ctx context.Context, client Querier, moduleId string) error {
+	_, err := SetAppTile(ctx, client, SetAppTileVariables{
+		Input: SetPublicAppTileDraftModuleSourceInput{
+			ModuleId:   moduleId,
+			SourceInfo: PublicAppTileSourceInput{Id: s.Id},
+		},
+	})
+	return err
+}
+
+type SurveySource struct {
+	Id string `json:"id"`
+}
+
+func (s *SurveySource) Category() ModuleCategory {
+	return ModuleCategorySurvey
+}
+
+func (s *SurveySource) SetDraftSource(ctx context.Context, client Querier, moduleId string) error {
+	_, err := SetSurvey(ctx, client, SetSurveyVariables{
+		Input: SetSurveyDraftModuleSourceInput{
+			ModuleId:   moduleId,
+			SourceInfo: SurveySourceInput{Id: s.Id},
+		},
+	})
+	return err
+}
+
+type MessageSource struct {
+	Id string `json:"id"`
+}
+
+func (s *MessageSource) Category() ModuleCategory {
+	return ModuleCategoryMessage
+}
+
+func (s *MessageSource) SetDraftSource(ctx context.Context, client Querier, moduleId string) error {
+	_, err := SetMessage(ctx, client, SetMessageVariables{
+		Input: SetMessageDraftModuleSourceInput{
+			ModuleId:   moduleId,
+			SourceInfo: MessageSourceInput{Id: s.Id},
+		},
+	})
+	return err
+}
+
+// UnknownModuleSource is a source of a type that is not registered, it keeps
+// the fields that were returned
+type UnknownModuleSource struct {
+	Typename string
+	Fields   map[string]interface{}
+}
+
+func (s *UnknownModuleSource) Category() ModuleCategory {
+	return ""
+}
+
+func (s *UnknownModuleSource) SetDraftSource(ctx context.Context, client Querier, moduleId string) error {
+	return fmt.Errorf("can't set a source of unknown type %s, register it with RegisterModuleSource", s.Typename)
+}
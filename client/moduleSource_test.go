@@ -0,0 +1,164 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/lifeomic/phc-sdk-go/graphql"
+)
+
+func TestModuleQueryMatchesSchema(t *testing.T) {
+	validator := loadValidator(t, "../schema/marketplace.graphql")
+	client := MarketplaceClient{}
+	if err := validator.ValidateDocument(client.moduleQuery()); err != nil {
+		t.Fatal("The module query does not match the schema", err)
+	}
+}
+
+func TestGetModuleDecodesSource(t *testing.T) {
+	mockClient := MockClient{
+		response: &map[string]interface{}{
+			"myModule": map[string]interface{}{
+				"id":       "module",
+				"title":    "survey module",
+				"version":  "1.0.0",
+				"category": "SURVEY",
+				"source":   map[string]interface{}{"__typename": "Survey", "id": "survey-id"},
+			},
+		},
+	}
+	client := MarketplaceClient{client: &mockClient}
+	module, err := client.GetModule("module")
+	if err != nil {
+		t.Fatal("Unexpected error", err)
+	}
+	if _, ok := module.Source.(*UnknownModuleSource); !ok || strings.Contains(mockClient.operations[0], "... on Survey") {
+		t.Fatal("Only app tile sources should be registered by default", module.Source)
+	}
+
+	mockClient.operations = nil
+	mockClient.variables = nil
+	client.RegisterModuleSource(SURVEY_MODULE_SOURCE)
+	module, err = client.GetModule("module")
+	if err != nil {
+		t.Fatal("Unexpected error", err)
+	}
+	survey, ok := module.Source.(*SurveySource)
+	if !ok || survey.Id != "survey-id" {
+		t.Fatal("Did not decode the survey source", module.Source)
+	}
+	if module.Category != ModuleCategorySurvey || module.Title != "survey module" {
+		t.Fatal("Did not decode the module", module)
+	}
+	if !strings.Contains(mockClient.operations[0], "... on Survey") || mockClient.variables[0]["id"] != "module" {
+		t.Fatal("Did not select every source type", mockClient.operations[0])
+	}
+	if _, ok := mockClient.variables[0]["version"]; ok {
+		t.Fatal("Should get the latest version without a version", mockClient.variables[0])
+	}
+
+	if _, err := client.GetModuleVersion("module", "1.0.0"); err != nil {
+		t.Fatal("Unexpected error", err)
+	}
+	if mockClient.variables[1]["version"] != "1.0.0" {
+		t.Fatal("Did not send the version", mockClient.variables[1])
+	}
+}
+
+func TestDecodeUnknownModuleSource(t *testing.T) {
+	client := MarketplaceClient{}
+	source, err := client.DecodeModuleSource(map[string]interface{}{"__typename": "Notebook", "id": "notebook"})
+	if err != nil {
+		t.Fatal("Unexpected error", err)
+	}
+	unknown, ok := source.(*UnknownModuleSource)
+	if !ok || unknown.Typename != "Notebook" || unknown.Fields["id"] != "notebook" {
+		t.Fatal("Expected an unknown source", source)
+	}
+	if err := unknown.SetDraftSource(context.Background(), nil, "module"); err == nil {
+		t.Fatal("Unknown sources can't be set")
+	}
+
+	if _, err := client.DecodeModuleSource(map[string]interface{}{"id": "tile"}); err == nil {
+		t.Fatal("Expected an error for a source without __typename")
+	}
+}
+
+type notebookSource struct {
+	Id   string `json:"id"`
+	Name string `json:"name"`
+}
+
+func (s *notebookSource) Category() ModuleCategory { return "NOTEBOOK" }
+
+func (s *notebookSource) SetDraftSource(ctx context.Context, client Querier, moduleId string) error {
+	return nil
+}
+
+func TestRegisterModuleSource(t *testing.T) {
+	lambdaClient := LambdaClient{}
+	client := lambdaClient.Marketplace()
+	other := MarketplaceClient{}
+	client.RegisterModuleSource(ModuleSourceType{
+		Typename:   "Notebook",
+		Selections: graphql.Fields("id", "name"),
+		New:        func() ModuleSource { return &notebookSource{} },
+	})
+
+	if !strings.Contains(client.moduleQuery(), "... on Notebook {\n\t\t\t\tid\n\t\t\t\tname") {
+		t.Fatal("Registered sources should be selected", client.moduleQuery())
+	}
+	if !strings.Contains(client.moduleQuery(), "... on AppTile") {
+		t.Fatal("The default sources should still be selected", client.moduleQuery())
+	}
+	if strings.Contains(other.moduleQuery(), "Notebook") {
+		t.Fatal("Sources registered on one client should not change another", other.moduleQuery())
+	}
+	client = lambdaClient.Marketplace()
+	source, err := client.DecodeModuleSource(map[string]interface{}{"__typename": "Notebook", "id": "notebook", "name": "analysis"})
+	if err != nil {
+		t.Fatal("Unexpected error", err)
+	}
+	if notebook, ok := source.(*notebookSource); !ok || notebook.Name != "analysis" {
+		t.Fatal("Registered sources should be kept by the LambdaClient", source)
+	}
+}
+
+func TestCreateDraftModuleOfCategory(t *testing.T) {
+	mockClient := MockClient{
+		response: &map[string]interface{}{
+			"createDraftModule": map[string]interface{}{"id": "draft"},
+			"startUpload":       map[string]interface{}{"id": "upload", "url": "https://uploads.test/url"},
+		},
+	}
+	image := filepath.Join(t.TempDir(), "icon.png")
+	if err := os.WriteFile(image, pngImage(t, 32, 32), 0600); err != nil {
+		t.Fatal(err)
+	}
+	client := MarketplaceClient{client: &mockClient, httpClient: &http.Client{Transport: &imageTransport{}}}
+	id, err := client.CreateDraftModule(ModuleCreate{
+		Name:   "survey",
+		Image:  image,
+		Source: &SurveySource{Id: "survey-id"},
+	})
+	if err != nil {
+		t.Fatal("Unexpected error", err)
+	}
+	if *id != "draft" {
+		t.Fatal("Unexpected id", *id)
+	}
+	if mockClient.variables[0]["input"].(map[string]interface{})["category"] != "SURVEY" {
+		t.Fatal("Did not create a survey module", mockClient.variables[0])
+	}
+	if mockClient.operations[1] != SetSurveyDocument {
+		t.Fatal("Did not set the survey source", mockClient.operations[1])
+	}
+
+	if _, err := client.CreateDraftModule(ModuleCreate{Name: "no source"}); err == nil {
+		t.Fatal("Expected an error without a source")
+	}
+}
@@ -0,0 +1,112 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/retry"
+)
+
+// DEFAULT_PUBLISH_TIMEOUT stops WaitForModulePublished when the context has no
+// deadline
+const DEFAULT_PUBLISH_TIMEOUT = 30 * time.Minute
+
+// ModuleState is the publishing status of a module version
+type ModuleState struct {
+	Id      string
+	Version string
+	// Found is false until the version is visible
+	Found  bool
+	Status ModuleStatus
+	Reason string
+}
+
+func (s ModuleState) String() string {
+	if !s.Found {
+		return "not visible yet"
+	}
+	if s.Reason != "" {
+		return fmt.Sprintf("%s (%s)", s.Status, s.Reason)
+	}
+	return string(s.Status)
+}
+
+// ModulePublishError is returned when a module version is rejected or fails
+// to publish
+type ModulePublishError struct {
+	State ModuleState
+}
+
+func (e *ModulePublishError) Error() string {
+	return fmt.Sprintf("module %s version %s was not published: %s", e.State.Id, e.State.Version, e.State)
+}
+
+// GetModuleState returns the publishing status of the module version
+func (self *MarketplaceClient) GetModuleState(ctx context.Context, id string, version string) (ModuleState, error) {
+	state := ModuleState{Id: id, Version: version}
+	res, err := GetModuleStatus(ctx, self, GetModuleStatusVariables{Id: id, Version: Some(version)})
+	if err != nil {
+		return state, err
+	}
+	if res.MyModule == nil || res.MyModule.Version != version {
+		return state, nil
+	}
+	state.Found = true
+	state.Status = res.MyModule.Status
+	if res.MyModule.StatusReason != nil {
+		state.Reason = *res.MyModule.StatusReason
+	}
+	return state, nil
+}
+
+// WaitForModulePublished polls the module version until it is published,
+// see PollUntil for the options. onState, which may be nil, is called every
+// time the state changes, such as when the version goes into review. A
+// rejected or failed version returns a *ModulePublishError. Throttled and
+// failed invocations are retried until the context is done, other errors stop
+// waiting. Without a deadline on the context it waits at most
+// DEFAULT_PUBLISH_TIMEOUT.
+func (self *MarketplaceClient) WaitForModulePublished(ctx context.Context, id string, version string, onState func(ModuleState), options ...PollOption) (ModuleState, error) {
+	defaults := []PollOption{PollRetry(isTransientStateError)}
+	if _, ok := ctx.Deadline(); !ok {
+		defaults = append(defaults, PollTimeout(DEFAULT_PUBLISH_TIMEOUT))
+	}
+	options = append(defaults, options...)
+	var previous *ModuleState
+	state, err := PollUntil(ctx, func(ctx context.Context) (ModuleState, bool, error) {
+		state, err := self.GetModuleState(ctx, id, version)
+		if err != nil {
+			return state, false, err
+		}
+		if onState != nil && (previous == nil || *previous != state) {
+			onState(state)
+		}
+		previous = &state
+		switch state.Status {
+		case ModuleStatusPublished:
+			return state, true, nil
+		case ModuleStatusRejected, ModuleStatusFailed:
+			return state, true, &ModulePublishError{State: state}
+		}
+		return state, false, nil
+	}, options...)
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) {
+		return state, fmt.Errorf("module %s version %s is %s: %w", id, version, state, err)
+	}
+	return state, err
+}
+
+// isTransientStateError reports whether reading the module state may succeed
+// when it is retried. Only invocations the AWS SDK gave up retrying and
+// errors it considers retryable, such as throttling and connection errors,
+// are transient. GraphQL, validation and decode errors are final.
+func isTransientStateError(err error) bool {
+	var exhausted *retry.MaxAttemptsError
+	if errors.As(err, &exhausted) {
+		return true
+	}
+	return retry.IsErrorRetryables(retry.DefaultRetryables).IsErrorRetryable(err) == aws.TrueTernary
+}
@@ -0,0 +1,143 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/lifeomic/phc-sdk-go/graphql"
+)
+
+// moduleVersionsBatchSize is how many versions are fetched by one query
+const moduleVersionsBatchSize = 25
+
+// AppTileModuleVersion is a published version of an app tile module with its
+// images
+type AppTileModuleVersion = GetAppTileModuleVersionMyModule
+
+// GetAppTileModuleVersion returns the given published version of the module,
+// or nil if the module or the version doesn't exist
+func (self *MarketplaceClient) GetAppTileModuleVersion(id string, version string) (*AppTileModuleVersion, error) {
+	res, err := GetAppTileModuleVersion(context.Background(), self, GetAppTileModuleVersionVariables{
+		Id:      id,
+		Version: Some(version),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return res.MyModule, nil
+}
+
+// ListAppTileModuleVersions returns every published version of the module
+// with its metadata, in the order ListModuleVersions returns them
+func (self *MarketplaceClient) ListAppTileModuleVersions(id string) ([]AppTileModuleVersion, error) {
+	ctx := context.Background()
+	versions, err := self.listModuleVersions(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	modules := make([]AppTileModuleVersion, 0, len(versions))
+	for start := 0; start < len(versions); start += moduleVersionsBatchSize {
+		end := start + moduleVersionsBatchSize
+		if end > len(versions) {
+			end = len(versions)
+		}
+		batch, err := self.getAppTileModuleVersions(ctx, id, versions[start:end])
+		if err != nil {
+			return nil, err
+		}
+		modules = append(modules, batch...)
+	}
+	return modules, nil
+}
+
+// getAppTileModuleVersions fetches the versions in one query, with an aliased
+// `myModule` field per version
+func (self *MarketplaceClient) getAppTileModuleVersions(ctx context.Context, id string, versions []string) ([]AppTileModuleVersion, error) {
+	query := graphql.Query("GetModuleVersions")
+	variables := map[string]interface{}{"id": id}
+	for i, version := range versions {
+		variable := fmt.Sprintf("version%d", i)
+		variables[variable] = version
+		query.Select(graphql.Field("myModule").
+			Alias(fmt.Sprintf("v%d", i)).
+			Arg("moduleId", graphql.Var("id", "ID!")).
+			Arg("version", graphql.Var(variable, "String")).
+			Select(graphql.Fields("title", "description", "version")...).
+			Select(
+				graphql.Field("source", graphql.On("AppTile", graphql.Field("id"))),
+				graphql.Field("iconV2", imageFields()...),
+				graphql.Field("images", imageFields()...),
+			))
+	}
+	document, err := query.Build()
+	if err != nil {
+		return nil, err
+	}
+	res, err := self.GqlWithContext(ctx, document, variables)
+	if err != nil {
+		return nil, err
+	}
+	var decoded map[string]*AppTileModuleVersion
+	if err := DecodeData(res, &decoded); err != nil {
+		return nil, err
+	}
+	modules := make([]AppTileModuleVersion, 0, len(versions))
+	for i, version := range versions {
+		module := decoded[fmt.Sprintf("v%d", i)]
+		if module == nil {
+			return nil, fmt.Errorf("version %s of module %s was not returned", version, id)
+		}
+		modules = append(modules, *module)
+	}
+	return modules, nil
+}
+
+// MODULE_FIELD_IMAGES is compared by DiffAppTileModules besides the fields
+// compared by EnsureAppTileModule
+const MODULE_FIELD_IMAGES = "images"
+
+// DiffAppTileModules returns the fields that differ between two versions of a
+// module, From holding the value in a and To the value in b. The icon and the
+// other images are compared by id.
+func DiffAppTileModules(a *AppTileModuleVersion, b *AppTileModuleVersion) []FieldChange {
+	var changes []FieldChange
+	compare := func(field string, from string, to string) {
+		if from != to {
+			changes = append(changes, FieldChange{Field: field, From: from, To: to})
+		}
+	}
+	compare(MODULE_FIELD_VERSION, a.Version, b.Version)
+	compare(MODULE_FIELD_TITLE, a.Title, b.Title)
+	compare(MODULE_FIELD_DESCRIPTION, a.Description, b.Description)
+	compare(MODULE_FIELD_APP_TILE_ID, a.Source.Id, b.Source.Id)
+	compare(MODULE_FIELD_ICON, imageId(a.IconV2), imageId(b.IconV2))
+	compare(MODULE_FIELD_IMAGES, describeImages(a.Images), describeImages(b.Images))
+	return changes
+}
+
+// CompareAppTileModuleVersions fetches two versions of the module and returns
+// their differences, see DiffAppTileModules
+func (self *MarketplaceClient) CompareAppTileModuleVersions(id string, from string, to string) ([]FieldChange, error) {
+	modules, err := self.getAppTileModuleVersions(context.Background(), id, []string{from, to})
+	if err != nil {
+		return nil, err
+	}
+	return DiffAppTileModules(&modules[0], &modules[1]), nil
+}
+
+func imageId(image *ModuleImage) string {
+	if image == nil {
+		return ""
+	}
+	return image.Id
+}
+
+// describeImages lists the images in order as `TYPE:id`
+func describeImages(images []ModuleImage) string {
+	described := make([]string, 0, len(images))
+	for _, image := range images {
+		described = append(described, fmt.Sprintf("%s:%s", image.Type, image.Id))
+	}
+	return strings.Join(described, ", ")
+}
@@ -0,0 +1,103 @@
+package client
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/lifeomic/phc-sdk-go/graphql"
+)
+
+func versionModule(version string, description string, iconName string) map[string]interface{} {
+	return map[string]interface{}{
+		"title":       "Tile",
+		"description": description,
+		"version":     version,
+		"source":      map[string]interface{}{"id": "app-tile-id"},
+		"iconV2":      map[string]interface{}{"id": iconName, "url": "https://icons/" + iconName, "fileName": "icon", "fileExtension": "png"},
+		"images":      []interface{}{},
+	}
+}
+
+func TestGetAppTileModuleVersion(t *testing.T) {
+	mockClient := MockClient{
+		response: &map[string]interface{}{"myModule": versionModule("1.2.0", "description", "icon")},
+	}
+	client := MarketplaceClient{client: &mockClient}
+	module, err := client.GetAppTileModuleVersion("module", "1.2.0")
+	if err != nil {
+		t.Fatal("Unexpected error", err)
+	}
+	if module.Version != "1.2.0" || mockClient.variables[0]["version"] != "1.2.0" {
+		t.Fatal("Did not request the version", mockClient.variables[0])
+	}
+}
+
+func TestListAppTileModuleVersions(t *testing.T) {
+	mockClient := MockClient{
+		response: &map[string]interface{}{
+			"myModule": map[string]interface{}{
+				"id":       "module",
+				"versions": []interface{}{map[string]interface{}{"version": "1.0.0"}, map[string]interface{}{"version": "1.1.0"}},
+			},
+			"v0": versionModule("1.0.0", "first", "icon"),
+			"v1": versionModule("1.1.0", "second", "icon-v2"),
+		},
+	}
+	client := MarketplaceClient{client: &mockClient}
+	modules, err := client.ListAppTileModuleVersions("module")
+	if err != nil {
+		t.Fatal("Unexpected error", err)
+	}
+	if len(modules) != 2 || modules[0].Description != "first" || modules[1].Version != "1.1.0" {
+		t.Fatal("Unexpected versions", modules)
+	}
+
+	query, variables := mockClient.operations[1], mockClient.variables[1]
+	if !strings.Contains(query, "v1: myModule(moduleId: $id, version: $version1)") || variables["version1"] != "1.1.0" {
+		t.Fatal("Did not alias the versions", query, variables)
+	}
+	if err := loadValidator(t, "../schema/marketplace.graphql").Validate(query, variables); err != nil {
+		t.Fatal("Query does not match the schema", err)
+	}
+
+	changes := DiffAppTileModules(&modules[0], &modules[1])
+	expected := []FieldChange{
+		{Field: MODULE_FIELD_VERSION, From: "1.0.0", To: "1.1.0"},
+		{Field: MODULE_FIELD_DESCRIPTION, From: "first", To: "second"},
+		{Field: MODULE_FIELD_ICON, From: "icon", To: "icon-v2"},
+	}
+	if len(changes) != len(expected) {
+		t.Fatal("Unexpected changes", changes)
+	}
+	for i := range expected {
+		if changes[i] != expected[i] {
+			t.Fatal("Unexpected change", changes[i])
+		}
+	}
+}
+
+func TestImageFieldsMatchFragment(t *testing.T) {
+	fragment := GET_DRAFT_MODULE_IMAGES[strings.Index(GET_DRAFT_MODULE_IMAGES, "fragment ImageFields on Image {"):]
+	fragment = fragment[strings.Index(fragment, "{")+1 : strings.Index(fragment, "}")]
+	expected := strings.Fields(fragment)
+	built := graphql.Query("Images", graphql.Field("image", imageFields()...)).MustBuild()
+	for _, field := range expected {
+		if !strings.Contains(built, "\t"+field+"\n") {
+			t.Fatal("Built queries should select the fragment fields", field, built)
+		}
+	}
+	if len(imageFields()) != len(expected) {
+		t.Fatal("Built queries should select only the fragment fields", built)
+	}
+}
+
+func TestCompareAppTileModuleVersionsReportsMissingVersions(t *testing.T) {
+	mockClient := MockClient{
+		response: &map[string]interface{}{"v0": versionModule("1.0.0", "first", "icon")},
+	}
+	client := MarketplaceClient{client: &mockClient}
+	_, err := client.CompareAppTileModuleVersions("module", "1.0.0", "9.9.9")
+	if err == nil || err.Error() != "version 9.9.9 of module module was not returned" {
+		t.Fatal("Expected a missing version error", err)
+	}
+}
@@ -0,0 +1,84 @@
+package client
+
+import (
+	"fmt"
+
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/parser"
+)
+
+type operationDefinition struct {
+	Kind string
+	Name string
+}
+
+// label names the operation for logs and metrics
+func (o operationDefinition) label() string {
+	if o.Name != "" {
+		return o.Name
+	}
+	return o.Kind
+}
+
+// GqlOption changes how a single GraphQL call is sent
+type GqlOption func(*gqlOptions)
+
+type gqlOptions struct {
+	operationName string
+}
+
+// WithOperation runs the named operation of a document that defines several
+// operations. Documents with a single operation don't need it, their
+// operation name is sent automatically.
+func WithOperation(name string) GqlOption {
+	return func(o *gqlOptions) {
+		o.operationName = name
+	}
+}
+
+func applyGqlOptions(options []GqlOption) gqlOptions {
+	var result gqlOptions
+	for _, option := range options {
+		option(&result)
+	}
+	return result
+}
+
+// selectOperation returns the operation of the document that runs when name
+// is sent as the operation name. Without a name the document must define a
+// single operation.
+func selectOperation(document string, name string) (operationDefinition, error) {
+	operations, err := parseOperations(document)
+	if err != nil {
+		return operationDefinition{}, fmt.Errorf("invalid GraphQL document: %w", err)
+	}
+	if name != "" {
+		for _, operation := range operations {
+			if operation.Name == name {
+				return operation, nil
+			}
+		}
+		return operationDefinition{}, fmt.Errorf("operation %s is not defined in the document", name)
+	}
+	switch len(operations) {
+	case 0:
+		return operationDefinition{}, nil
+	case 1:
+		return operations[0], nil
+	default:
+		return operationDefinition{}, fmt.Errorf("the document defines %d operations, select one with WithOperation", len(operations))
+	}
+}
+
+// parseOperations returns the operations defined by a GraphQL document
+func parseOperations(document string) ([]operationDefinition, error) {
+	parsed, err := parser.ParseQuery(&ast.Source{Input: document})
+	if err != nil {
+		return nil, err
+	}
+	operations := make([]operationDefinition, 0, len(parsed.Operations))
+	for _, operation := range parsed.Operations {
+		operations = append(operations, operationDefinition{Kind: string(operation.Operation), Name: operation.Name})
+	}
+	return operations, nil
+}
@@ -0,0 +1,52 @@
+package client
+
+import (
+	"testing"
+)
+
+func TestParseOperations(t *testing.T) {
+	operations, err := parseOperations(`
+		# mutation Commented { out }
+		query GetThing($id: ID = "mutation {") @cached { thing(id: $id) { name } }
+		fragment Parts on Thing { name }
+		mutation SetThing { setThing { id } }
+		{ anonymous }
+	`)
+	expected := []operationDefinition{
+		{Kind: "query", Name: "GetThing"},
+		{Kind: "mutation", Name: "SetThing"},
+		{Kind: "query"},
+	}
+	if err != nil {
+		t.Fatal("Unexpected error", err)
+	}
+	if len(operations) != len(expected) {
+		t.Fatal("Did not find every operation", operations)
+	}
+	for i, operation := range operations {
+		if operation != expected[i] {
+			t.Fatal("Did not parse operation right", operation, expected[i])
+		}
+	}
+}
+
+func TestSelectOperation(t *testing.T) {
+	operation, err := selectOperation(MOCK_MUTATION, "")
+	if err != nil || operation != (operationDefinition{Kind: "mutation", Name: "MockMutation"}) {
+		t.Fatal("Did not select the only operation", operation, err)
+	}
+
+	document := GET_PUBLISHED_APP_TILE_MODULE + MOCK_MUTATION
+	operation, err = selectOperation(document, "MockMutation")
+	if err != nil || operation.Kind != "mutation" {
+		t.Fatal("Did not select the named operation", operation, err)
+	}
+	_, err = selectOperation(document, "")
+	if err == nil || err.Error() != "the document defines 2 operations, select one with WithOperation" {
+		t.Fatal("Expected an error for an ambiguous document", err)
+	}
+	_, err = selectOperation(document, "Missing")
+	if err == nil || err.Error() != "operation Missing is not defined in the document" {
+		t.Fatal("Expected an error for an unknown operation", err)
+	}
+}
@@ -0,0 +1,89 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// Optional is a nullable variable or input field that distinguishes leaving
+// the field out from setting it to null. StructToVariables only sends fields
+// that were set, so partial updates change nothing but the fields the caller
+// chose:
+//
+//	EditWebAppInput{Name: Some("New name"), Image: Null[string]()}
+//
+// sends `{"name": "New name", "image": null}` and leaves everything else as
+// it is. The zero value is unset.
+type Optional[T any] struct {
+	value T
+	set   bool
+	null  bool
+}
+
+// Some sets the field to value
+func Some[T any](value T) Optional[T] {
+	return Optional[T]{value: value, set: true}
+}
+
+// Null sets the field to an explicit null
+func Null[T any]() Optional[T] {
+	return Optional[T]{set: true, null: true}
+}
+
+// FromPointer is Some for non nil pointers and unset otherwise
+func FromPointer[T any](value *T) Optional[T] {
+	if value == nil {
+		return Optional[T]{}
+	}
+	return Some(*value)
+}
+
+func (o Optional[T]) IsSet() bool {
+	return o.set
+}
+
+func (o Optional[T]) IsNull() bool {
+	return o.set && o.null
+}
+
+// Get returns the value and whether the field was set to a non null value
+func (o Optional[T]) Get() (T, bool) {
+	return o.value, o.set && !o.null
+}
+
+// variableValue implements optionalValue for StructToVariables
+func (o Optional[T]) variableValue() (interface{}, bool) {
+	if !o.set {
+		return nil, false
+	}
+	if o.null {
+		return nil, true
+	}
+	return o.value, true
+}
+
+// MarshalJSON writes unset values as null, StructToVariables leaves them out
+// instead
+func (o Optional[T]) MarshalJSON() ([]byte, error) {
+	if !o.set || o.null {
+		return []byte("null"), nil
+	}
+	return json.Marshal(o.value)
+}
+
+func (o *Optional[T]) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(bytes.TrimSpace(data), []byte("null")) {
+		*o = Null[T]()
+		return nil
+	}
+	var value T
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+	*o = Some(value)
+	return nil
+}
+
+type optionalValue interface {
+	variableValue() (interface{}, bool)
+}
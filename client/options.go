@@ -0,0 +1,100 @@
+package client
+
+import "net/http"
+
+// Option configures optional behavior of a LambdaClient built with BuildClient
+type Option func(*LambdaClient)
+
+// WithoutRequestCoalescing sends every query as its own invocation, even when
+// an identical query is already in flight
+func WithoutRequestCoalescing() Option {
+	return func(c *LambdaClient) {
+		c.disableCoalescing = true
+	}
+}
+
+// WithMetrics reports every invocation to the recorder in addition to the
+// client's own Stats
+func WithMetrics(recorder MetricsRecorder) Option {
+	return func(c *LambdaClient) {
+		c.metrics = append(c.metrics, recorder)
+	}
+}
+
+// WithLogger writes debug records for every request and response. Sensitive
+// headers are always masked, see WithRedactedVariables for masking variables.
+func WithLogger(logger Logger) Option {
+	return func(c *LambdaClient) {
+		c.logger = logger
+	}
+}
+
+// WithRedactedVariables masks the variables at the given paths before requests
+// are logged, see Redactor for the path syntax
+func WithRedactedVariables(paths ...string) Option {
+	return func(c *LambdaClient) {
+		c.redactor.VariablePaths = append(c.redactor.VariablePaths, paths...)
+	}
+}
+
+// WithRedactedHeaders masks additional request headers before requests are
+// logged
+func WithRedactedHeaders(headers ...string) Option {
+	return func(c *LambdaClient) {
+		c.redactor.Headers = append(c.redactor.Headers, headers...)
+	}
+}
+
+// WrapInvoker replaces the client's invoker with the result of wrap, which
+// receives the current invoker. Wrappers such as HarRecorder are installed
+// this way.
+func WrapInvoker(wrap func(Invoker) Invoker) Option {
+	return func(c *LambdaClient) {
+		c.invoker = wrap(c.invoker)
+	}
+}
+
+// WithDryRun records mutations and non-GET Do requests in dryRun instead of
+// sending them, see DryRun for the synthetic results returned
+func WithDryRun(dryRun *DryRun) Option {
+	return func(c *LambdaClient) {
+		c.dryRun = dryRun
+	}
+}
+
+// WithIdempotencyGuard guards create mutations made with an idempotency key
+// against creating duplicates, see IdempotencyGuard
+func WithIdempotencyGuard(guard IdempotencyGuard) Option {
+	return func(c *LambdaClient) {
+		c.guard = guard
+	}
+}
+
+// WithHttpClient sends the requests made outside the lambda, such as image
+// uploads to their presigned urls, with httpClient instead of
+// http.DefaultClient
+func WithHttpClient(httpClient *http.Client) Option {
+	return func(c *LambdaClient) {
+		c.httpClient = httpClient
+	}
+}
+
+// WithImageValidation checks images against the rules of their type, see
+// ImageRulesFor, before they are uploaded to the marketplace. Without it
+// images are only checked by the service.
+func WithImageValidation() Option {
+	return func(c *LambdaClient) {
+		c.validateImages = true
+	}
+}
+
+// WithValidator checks every operation sent to uri with the validator and
+// returns its error instead of invoking the service
+func WithValidator(uri string, validator OperationValidator) Option {
+	return func(c *LambdaClient) {
+		if c.validators == nil {
+			c.validators = map[string]OperationValidator{}
+		}
+		c.validators[uri] = validator
+	}
+}
@@ -0,0 +1,229 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Variables set by the iterator on every page request. Connection queries
+// must declare them, for example `($first: Int, $after: String)`.
+const (
+	PAGE_SIZE_VARIABLE = "first"
+	CURSOR_VARIABLE    = "after"
+)
+
+// ErrStopPaging can be returned from a ForEach callback to stop without error
+var ErrStopPaging = errors.New("stop paging")
+
+type PageInfo struct {
+	HasNextPage bool    `json:"hasNextPage"`
+	EndCursor   *string `json:"endCursor"`
+}
+
+type Edge[T any] struct {
+	Cursor string `json:"cursor"`
+	Node   T      `json:"node"`
+}
+
+// Connection is a Relay style connection field
+type Connection[T any] struct {
+	Edges    []Edge[T] `json:"edges"`
+	PageInfo PageInfo  `json:"pageInfo"`
+}
+
+type PageOption func(*pageConfig)
+
+type pageConfig struct {
+	pageSize int
+	maxNodes int
+}
+
+// PageSize sets the number of nodes requested per page
+func PageSize(size int) PageOption {
+	return func(c *pageConfig) {
+		c.pageSize = size
+	}
+}
+
+// MaxNodes stops the iterator after max nodes, fetching no more pages
+func MaxNodes(max int) PageOption {
+	return func(c *pageConfig) {
+		c.maxNodes = max
+	}
+}
+
+// Iterator walks the nodes of a connection, fetching the next page with the
+// previous page's end cursor when the current one is exhausted:
+//
+//	modules := Paginate[Module](marketplace, LIST_MODULES, nil, "myModules", PageSize(50))
+//	for modules.Next(ctx) {
+//		fmt.Println(modules.Node().Title)
+//	}
+//	if err := modules.Err(); err != nil {
+//		...
+//	}
+//
+// Stopping early is just a matter of not calling Next again.
+type Iterator[T any] struct {
+	client    Querier
+	query     string
+	variables map[string]interface{}
+	path      []string
+	config    pageConfig
+
+	page    []Edge[T]
+	index   int
+	cursor  *string
+	hasNext bool
+	started bool
+	yielded int
+	err     error
+}
+
+// Paginate iterates over the connection at path, a dot separated path to the
+// connection field in the response data such as `myModules` or
+// `app.versions`
+func Paginate[T any](client Querier, query string, variables map[string]interface{}, path string, options ...PageOption) *Iterator[T] {
+	it := &Iterator[T]{
+		client:    client,
+		query:     query,
+		variables: variables,
+		path:      strings.Split(path, "."),
+		index:     -1,
+	}
+	for _, option := range options {
+		option(&it.config)
+	}
+	if it.config.pageSize < 0 || it.config.maxNodes < 0 {
+		it.err = fmt.Errorf("page size and max nodes must not be negative")
+	}
+	return it
+}
+
+// Next advances to the next node, fetching a page if needed. It returns false
+// when there are no more nodes, the limit is reached or an error occurred.
+func (it *Iterator[T]) Next(ctx context.Context) bool {
+	if it.err != nil {
+		return false
+	}
+	if it.config.maxNodes > 0 && it.yielded >= it.config.maxNodes {
+		return false
+	}
+	if err := ctx.Err(); err != nil {
+		it.err = err
+		return false
+	}
+	it.index++
+	for it.index >= len(it.page) {
+		if it.started && !it.hasNext {
+			return false
+		}
+		if err := it.fetch(ctx); err != nil {
+			it.err = err
+			return false
+		}
+	}
+	it.yielded++
+	return true
+}
+
+func (it *Iterator[T]) fetch(ctx context.Context) error {
+	variables := make(map[string]interface{}, len(it.variables)+2)
+	for k, v := range it.variables {
+		variables[k] = v
+	}
+	if size := it.nextPageSize(); size > 0 {
+		variables[PAGE_SIZE_VARIABLE] = size
+	}
+	if it.cursor != nil {
+		variables[CURSOR_VARIABLE] = *it.cursor
+	}
+
+	data, err := it.client.GqlWithContext(ctx, it.query, variables)
+	if err != nil {
+		return err
+	}
+	var connection Connection[T]
+	if err := decodeConnection(data, it.path, &connection); err != nil {
+		return err
+	}
+
+	previous := it.cursor
+	it.started = true
+	it.page = connection.Edges
+	it.index = 0
+	it.hasNext = connection.PageInfo.HasNextPage
+	it.cursor = connection.PageInfo.EndCursor
+	if it.hasNext && (it.cursor == nil || previous != nil && *previous == *it.cursor) {
+		return fmt.Errorf("connection %s reports another page without a new end cursor", strings.Join(it.path, "."))
+	}
+	return nil
+}
+
+// nextPageSize doesn't request more nodes than the limit allows
+func (it *Iterator[T]) nextPageSize() int {
+	size := it.config.pageSize
+	if it.config.maxNodes > 0 {
+		remaining := it.config.maxNodes - it.yielded
+		if size == 0 || remaining < size {
+			size = remaining
+		}
+	}
+	return size
+}
+
+// Node returns the current node
+func (it *Iterator[T]) Node() T {
+	return it.page[it.index].Node
+}
+
+// Cursor returns the cursor of the current node, which can be used to resume
+// iteration later by passing it as the `after` variable
+func (it *Iterator[T]) Cursor() string {
+	return it.page[it.index].Cursor
+}
+
+// Err returns the error that stopped the iteration, if any
+func (it *Iterator[T]) Err() error {
+	return it.err
+}
+
+// ForEach calls fn with every node until the connection is exhausted or fn
+// returns an error. Returning ErrStopPaging stops without an error.
+func (it *Iterator[T]) ForEach(ctx context.Context, fn func(node T) error) error {
+	for it.Next(ctx) {
+		if err := fn(it.Node()); err != nil {
+			if errors.Is(err, ErrStopPaging) {
+				return nil
+			}
+			return err
+		}
+	}
+	return it.Err()
+}
+
+// All collects the remaining nodes
+func (it *Iterator[T]) All(ctx context.Context) ([]T, error) {
+	var nodes []T
+	for it.Next(ctx) {
+		nodes = append(nodes, it.Node())
+	}
+	return nodes, it.Err()
+}
+
+func decodeConnection(data *map[string]interface{}, path []string, out interface{}) error {
+	if data == nil {
+		return fmt.Errorf("no data returned for connection %s", strings.Join(path, "."))
+	}
+	connection := *data
+	for _, key := range path {
+		object, ok := connection[key].(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("no connection returned at %s", strings.Join(path, "."))
+		}
+		connection = object
+	}
+	return DecodeData(&connection, out)
+}
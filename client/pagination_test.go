@@ -0,0 +1,144 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"testing"
+)
+
+type pagedQuerier struct {
+	total    int
+	requests []map[string]interface{}
+}
+
+// GqlWithContext serves nodes 1..total under `myModules`, using the node
+// number as cursor and a default page size of 2
+func (q *pagedQuerier) GqlWithContext(ctx context.Context, query string, variables map[string]interface{}, options ...GqlOption) (*map[string]interface{}, error) {
+	q.requests = append(q.requests, variables)
+	start := 0
+	if after, ok := variables[CURSOR_VARIABLE].(string); ok {
+		start, _ = strconv.Atoi(after)
+	}
+	size := 2
+	if first, ok := variables[PAGE_SIZE_VARIABLE].(int); ok {
+		size = first
+	}
+	edges := []interface{}{}
+	end := start
+	for end < q.total && end < start+size {
+		end++
+		edges = append(edges, map[string]interface{}{
+			"cursor": strconv.Itoa(end),
+			"node":   map[string]interface{}{"title": fmt.Sprintf("module %d", end)},
+		})
+	}
+	data := map[string]interface{}{
+		"myModules": map[string]interface{}{
+			"edges":    edges,
+			"pageInfo": map[string]interface{}{"hasNextPage": end < q.total, "endCursor": strconv.Itoa(end)},
+		},
+	}
+	return &data, nil
+}
+
+type pagedModule struct {
+	Title string `json:"title"`
+}
+
+func TestPaginateAllPages(t *testing.T) {
+	querier := &pagedQuerier{total: 5}
+	nodes, err := Paginate[pagedModule](querier, "query", map[string]interface{}{"category": "APP_TILE"}, "myModules").All(context.Background())
+	if err != nil {
+		t.Fatal("Unexpected error", err)
+	}
+	if len(nodes) != 5 || nodes[0].Title != "module 1" || nodes[4].Title != "module 5" {
+		t.Fatal("Did not return every node", nodes)
+	}
+	if len(querier.requests) != 3 {
+		t.Fatal("Expected three pages", querier.requests)
+	}
+	if querier.requests[1][CURSOR_VARIABLE] != "2" || querier.requests[2][CURSOR_VARIABLE] != "4" {
+		t.Fatal("Did not pass the end cursor", querier.requests)
+	}
+	if querier.requests[2]["category"] != "APP_TILE" {
+		t.Fatal("Did not keep the variables", querier.requests[2])
+	}
+	if _, ok := querier.requests[0][CURSOR_VARIABLE]; ok {
+		t.Fatal("The first page should not send a cursor", querier.requests[0])
+	}
+}
+
+func TestPaginatePageSizeAndLimit(t *testing.T) {
+	querier := &pagedQuerier{total: 100}
+	nodes, err := Paginate[pagedModule](querier, "query", nil, "myModules", PageSize(3), MaxNodes(7)).All(context.Background())
+	if err != nil {
+		t.Fatal("Unexpected error", err)
+	}
+	if len(nodes) != 7 {
+		t.Fatal("Did not stop at the limit", len(nodes))
+	}
+	if len(querier.requests) != 3 || querier.requests[0][PAGE_SIZE_VARIABLE] != 3 || querier.requests[2][PAGE_SIZE_VARIABLE] != 1 {
+		t.Fatal("Did not request pages within the limit", querier.requests)
+	}
+}
+
+func TestPaginateStopsEarly(t *testing.T) {
+	querier := &pagedQuerier{total: 100}
+	var titles []string
+	err := Paginate[pagedModule](querier, "query", nil, "myModules").ForEach(context.Background(), func(node pagedModule) error {
+		titles = append(titles, node.Title)
+		if len(titles) == 3 {
+			return ErrStopPaging
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal("Unexpected error", err)
+	}
+	if len(titles) != 3 || len(querier.requests) != 2 {
+		t.Fatal("Did not stop early", titles, len(querier.requests))
+	}
+}
+
+func TestPaginateCancellation(t *testing.T) {
+	querier := &pagedQuerier{total: 100}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	modules := Paginate[pagedModule](querier, "query", nil, "myModules")
+	count := 0
+	for modules.Next(ctx) {
+		count++
+		if count == 2 {
+			cancel()
+		}
+	}
+	if !errors.Is(modules.Err(), context.Canceled) {
+		t.Fatal("Expected a cancellation error", modules.Err())
+	}
+	if count != 2 || len(querier.requests) != 1 {
+		t.Fatal("Did not stop after cancellation", count, len(querier.requests))
+	}
+}
+
+func TestPaginateMissingConnection(t *testing.T) {
+	querier := &pagedQuerier{total: 1}
+	_, err := Paginate[pagedModule](querier, "query", nil, "app.versions").All(context.Background())
+	if err == nil || err.Error() != "no connection returned at app.versions" {
+		t.Fatal("Expected a missing connection error", err)
+	}
+}
+
+func TestPaginateRepeatedCursor(t *testing.T) {
+	client := &MockClient{response: &map[string]interface{}{
+		"myModules": map[string]interface{}{
+			"edges":    []interface{}{map[string]interface{}{"cursor": "a", "node": map[string]interface{}{"title": "a"}}},
+			"pageInfo": map[string]interface{}{"hasNextPage": true, "endCursor": "a"},
+		},
+	}}
+	_, err := Paginate[pagedModule](&ServiceClient{graphqlUrl: "uri", client: client}, "query", nil, "myModules").All(context.Background())
+	if err == nil {
+		t.Fatal("Expected an error instead of looping on the same cursor")
+	}
+}
@@ -0,0 +1,125 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+const (
+	DEFAULT_POLL_INTERVAL     = 2 * time.Second
+	DEFAULT_POLL_MAX_INTERVAL = 30 * time.Second
+	DEFAULT_POLL_BACKOFF      = 2.0
+)
+
+type pollConfig struct {
+	interval    time.Duration
+	maxInterval time.Duration
+	backoff     float64
+	timeout     time.Duration
+	retryable   func(error) bool
+}
+
+type PollOption func(*pollConfig)
+
+// PollInterval sets the wait before the second check, DEFAULT_POLL_INTERVAL
+// by default
+func PollInterval(interval time.Duration) PollOption {
+	return func(c *pollConfig) {
+		c.interval = interval
+	}
+}
+
+// PollMaxInterval caps the wait between checks, DEFAULT_POLL_MAX_INTERVAL by
+// default
+func PollMaxInterval(interval time.Duration) PollOption {
+	return func(c *pollConfig) {
+		c.maxInterval = interval
+	}
+}
+
+// PollBackoff multiplies the wait after every check, DEFAULT_POLL_BACKOFF by
+// default. A factor of 1 polls at a fixed interval.
+func PollBackoff(factor float64) PollOption {
+	return func(c *pollConfig) {
+		c.backoff = factor
+	}
+}
+
+// PollTimeout stops polling after the duration, in addition to the deadline of
+// the context
+func PollTimeout(timeout time.Duration) PollOption {
+	return func(c *pollConfig) {
+		c.timeout = timeout
+	}
+}
+
+// PollRetry keeps polling after errors for which retryable returns true, the
+// check is called again after the usual wait. By default every error stops
+// polling.
+func PollRetry(retryable func(error) bool) PollOption {
+	return func(c *pollConfig) {
+		c.retryable = retryable
+	}
+}
+
+// PollUntil calls check until it reports done, waiting longer between each
+// call. An error from check stops polling and is returned, unless it is
+// retryable, see PollRetry. When the context is done first the last value is
+// returned with the context's error, which also mentions the last retried
+// error.
+func PollUntil[T any](ctx context.Context, check func(ctx context.Context) (T, bool, error), options ...PollOption) (T, error) {
+	config := pollConfig{
+		interval:    DEFAULT_POLL_INTERVAL,
+		maxInterval: DEFAULT_POLL_MAX_INTERVAL,
+		backoff:     DEFAULT_POLL_BACKOFF,
+	}
+	for _, option := range options {
+		option(&config)
+	}
+	if config.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, config.timeout)
+		defer cancel()
+	}
+
+	interval := config.interval
+	var last T
+	var retried error
+	stopped := func() error {
+		if retried != nil {
+			return fmt.Errorf("%w, last error: %s", ctx.Err(), retried)
+		}
+		return ctx.Err()
+	}
+	for {
+		value, done, err := check(ctx)
+		if err != nil && ctx.Err() != nil {
+			// The check was cut short by the deadline
+			return last, stopped()
+		}
+		switch {
+		case err != nil && !done && config.retryable != nil && config.retryable(err):
+			retried = err
+		case err != nil || done:
+			return value, err
+		default:
+			last = value
+			retried = nil
+		}
+
+		timer := time.NewTimer(interval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return last, stopped()
+		case <-timer.C:
+		}
+		if config.backoff > 1 {
+			interval = time.Duration(float64(interval) * config.backoff)
+		}
+		if config.maxInterval > 0 && interval > config.maxInterval {
+			interval = config.maxInterval
+		}
+	}
+}
@@ -0,0 +1,250 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws/retry"
+)
+
+func TestPollUntil(t *testing.T) {
+	attempts := 0
+	value, err := PollUntil(context.Background(), func(ctx context.Context) (int, bool, error) {
+		attempts++
+		return attempts, attempts == 3, nil
+	}, PollInterval(time.Millisecond))
+	if err != nil || value != 3 {
+		t.Fatal("Unexpected result", value, err)
+	}
+
+	failure := errors.New("failed")
+	_, err = PollUntil(context.Background(), func(ctx context.Context) (int, bool, error) {
+		return 0, false, failure
+	}, PollInterval(time.Millisecond))
+	if err != failure {
+		t.Fatal("Errors should stop polling", err)
+	}
+}
+
+func TestPollUntilRetries(t *testing.T) {
+	transient := errors.New("throttled")
+	attempts := 0
+	value, err := PollUntil(context.Background(), func(ctx context.Context) (int, bool, error) {
+		attempts++
+		if attempts < 3 {
+			return 0, false, transient
+		}
+		return attempts, true, nil
+	}, PollInterval(time.Millisecond), PollRetry(func(err error) bool { return err == transient }))
+	if err != nil || value != 3 {
+		t.Fatal("Retryable errors should not stop polling", value, err)
+	}
+
+	failure := errors.New("failed")
+	_, err = PollUntil(context.Background(), func(ctx context.Context) (int, bool, error) {
+		return 0, false, failure
+	}, PollInterval(time.Millisecond), PollRetry(func(err error) bool { return err == transient }))
+	if err != failure {
+		t.Fatal("Other errors should stop polling", err)
+	}
+
+	_, err = PollUntil(context.Background(), func(ctx context.Context) (int, bool, error) {
+		return 0, false, transient
+	}, PollInterval(time.Millisecond), PollTimeout(20*time.Millisecond), PollRetry(func(err error) bool { return true }))
+	if !errors.Is(err, context.DeadlineExceeded) || err.Error() != "context deadline exceeded, last error: throttled" {
+		t.Fatal("Expected a timeout mentioning the last error", err)
+	}
+}
+
+func TestPollUntilTimesOut(t *testing.T) {
+	attempts := 0
+	start := time.Now()
+	value, err := PollUntil(context.Background(), func(ctx context.Context) (int, bool, error) {
+		attempts++
+		return attempts, false, nil
+	}, PollInterval(time.Millisecond), PollMaxInterval(4*time.Millisecond), PollTimeout(30*time.Millisecond))
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatal("Expected a timeout", err)
+	}
+	if value != attempts || attempts < 3 {
+		t.Fatal("Should return the last value", value, attempts)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatal("Did not stop at the timeout", elapsed)
+	}
+}
+
+func moduleStatus(status string, reason interface{}) *map[string]interface{} {
+	return &map[string]interface{}{
+		"myModule": map[string]interface{}{"id": "module", "version": "1.0.0", "status": status, "statusReason": reason},
+	}
+}
+
+// sequenceClient returns the responses in order, repeating the last one
+type sequenceClient struct {
+	MockClient
+	responses []*map[string]interface{}
+}
+
+func (s *sequenceClient) GqlWithContext(ctx context.Context, url string, operation string, variables map[string]interface{}, options ...GqlOption) (*map[string]interface{}, error) {
+	s.response = s.responses[0]
+	if len(s.responses) > 1 {
+		s.responses = s.responses[1:]
+	}
+	return s.MockClient.GqlWithContext(ctx, url, operation, variables, options...)
+}
+
+func TestWaitForModulePublished(t *testing.T) {
+	mockClient := sequenceClient{responses: []*map[string]interface{}{
+		{"myModule": nil},
+		moduleStatus("PROCESSING", nil),
+		moduleStatus("IN_REVIEW", nil),
+		moduleStatus("IN_REVIEW", nil),
+		moduleStatus("PUBLISHED", nil),
+	}}
+	client := MarketplaceClient{client: &mockClient}
+	var reported []string
+	state, err := client.WaitForModulePublished(context.Background(), "module", "1.0.0", func(state ModuleState) {
+		reported = append(reported, state.String())
+	}, PollInterval(time.Millisecond))
+	if err != nil {
+		t.Fatal("Unexpected error", err)
+	}
+	if state.Status != ModuleStatusPublished || len(mockClient.operations) != 5 {
+		t.Fatal("Unexpected state", state, mockClient.operations)
+	}
+	expected := []string{"not visible yet", "PROCESSING", "IN_REVIEW", "PUBLISHED"}
+	if len(reported) != len(expected) {
+		t.Fatal("Unexpected reported states", reported)
+	}
+	for i := range expected {
+		if reported[i] != expected[i] {
+			t.Fatal("Unexpected reported states", reported)
+		}
+	}
+	if mockClient.variables[0]["version"] != "1.0.0" {
+		t.Fatal("Did not request the version", mockClient.variables[0])
+	}
+}
+
+func TestWaitForModulePublishedFailures(t *testing.T) {
+	mockClient := sequenceClient{responses: []*map[string]interface{}{moduleStatus("REJECTED", "icon is blurry")}}
+	client := MarketplaceClient{client: &mockClient}
+	_, err := client.WaitForModulePublished(context.Background(), "module", "1.0.0", nil, PollInterval(time.Millisecond))
+	var publishError *ModulePublishError
+	if !errors.As(err, &publishError) || publishError.State.Reason != "icon is blurry" {
+		t.Fatal("Expected a publish error", err)
+	}
+	if err.Error() != "module module version 1.0.0 was not published: REJECTED (icon is blurry)" {
+		t.Fatal("Unexpected message", err.Error())
+	}
+
+	mockClient = sequenceClient{responses: []*map[string]interface{}{moduleStatus("IN_REVIEW", nil)}}
+	client = MarketplaceClient{client: &mockClient}
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	state, err := client.WaitForModulePublished(ctx, "module", "1.0.0", nil, PollInterval(time.Millisecond))
+	if !errors.Is(err, context.DeadlineExceeded) || state.Status != ModuleStatusInReview {
+		t.Fatal("Expected a timeout in review", state, err)
+	}
+	if err.Error() != "module module version 1.0.0 is IN_REVIEW: context deadline exceeded" {
+		t.Fatal("Unexpected message", err.Error())
+	}
+}
+
+// flakyClient fails the first calls before answering like sequenceClient
+type flakyClient struct {
+	sequenceClient
+	failures []error
+}
+
+func (f *flakyClient) GqlWithContext(ctx context.Context, url string, operation string, variables map[string]interface{}, options ...GqlOption) (*map[string]interface{}, error) {
+	if len(f.failures) > 0 {
+		err := f.failures[0]
+		f.failures = f.failures[1:]
+		f.operations = append(f.operations, operation)
+		return nil, err
+	}
+	return f.sequenceClient.GqlWithContext(ctx, url, operation, variables, options...)
+}
+
+func TestWaitForModulePublishedRetriesTransientErrors(t *testing.T) {
+	mockClient := flakyClient{
+		sequenceClient: sequenceClient{responses: []*map[string]interface{}{moduleStatus("PUBLISHED", nil)}},
+		failures: []error{
+			errorCode("TooManyRequestsException"),
+			&retry.MaxAttemptsError{Attempt: 3, Err: errors.New("connection reset")},
+		},
+	}
+	client := MarketplaceClient{client: &mockClient}
+	state, err := client.WaitForModulePublished(context.Background(), "module", "1.0.0", nil, PollInterval(time.Millisecond))
+	if err != nil || state.Status != ModuleStatusPublished || len(mockClient.operations) != 3 {
+		t.Fatal("Transient errors should be retried", state, err, mockClient.operations)
+	}
+
+	mockClient = flakyClient{
+		sequenceClient: sequenceClient{responses: []*map[string]interface{}{moduleStatus("PUBLISHED", nil)}},
+		failures:       []error{GqlError{Message: "module not found"}},
+	}
+	client = MarketplaceClient{client: &mockClient}
+	_, err = client.WaitForModulePublished(context.Background(), "module", "1.0.0", nil, PollInterval(time.Millisecond))
+	if err == nil || err.Error() != "module not found" || len(mockClient.operations) != 1 {
+		t.Fatal("GraphQL errors should stop polling", err, mockClient.operations)
+	}
+	for _, final := range []error{
+		json.Unmarshal([]byte("<html>"), &map[string]interface{}{}),
+		fmt.Errorf("invalid operation for %s: %w", MARKETPLACE_GRAPHQL_URL, errors.New("unknown field")),
+	} {
+		mockClient = flakyClient{
+			sequenceClient: sequenceClient{responses: []*map[string]interface{}{moduleStatus("PUBLISHED", nil)}},
+			failures:       []error{final},
+		}
+		client = MarketplaceClient{client: &mockClient}
+		_, err = client.WaitForModulePublished(context.Background(), "module", "1.0.0", nil, PollInterval(time.Millisecond))
+		if err != final || len(mockClient.operations) != 1 {
+			t.Fatal("Only invocation errors should be retried", err, mockClient.operations)
+		}
+	}
+}
+
+// errorCode is an error with an AWS error code
+type errorCode string
+
+func (e errorCode) Error() string     { return string(e) }
+func (e errorCode) ErrorCode() string { return string(e) }
+
+// deadlineClient records the deadline of the context of every call
+type deadlineClient struct {
+	sequenceClient
+	deadlines []time.Time
+}
+
+func (d *deadlineClient) GqlWithContext(ctx context.Context, url string, operation string, variables map[string]interface{}, options ...GqlOption) (*map[string]interface{}, error) {
+	deadline, _ := ctx.Deadline()
+	d.deadlines = append(d.deadlines, deadline)
+	return d.sequenceClient.GqlWithContext(ctx, url, operation, variables, options...)
+}
+
+func TestWaitForModulePublishedDefaultTimeout(t *testing.T) {
+	mockClient := deadlineClient{sequenceClient: sequenceClient{responses: []*map[string]interface{}{moduleStatus("PUBLISHED", nil)}}}
+	client := MarketplaceClient{client: &mockClient}
+	if _, err := client.WaitForModulePublished(context.Background(), "module", "1.0.0", nil); err != nil {
+		t.Fatal("Unexpected error", err)
+	}
+	if remaining := time.Until(mockClient.deadlines[0]); remaining <= 0 || remaining > DEFAULT_PUBLISH_TIMEOUT {
+		t.Fatal("Expected the default timeout without a deadline", mockClient.deadlines)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Hour)
+	defer cancel()
+	if _, err := client.WaitForModulePublished(ctx, "module", "1.0.0", nil); err != nil {
+		t.Fatal("Unexpected error", err)
+	}
+	if remaining := time.Until(mockClient.deadlines[1]); remaining <= DEFAULT_PUBLISH_TIMEOUT {
+		t.Fatal("The deadline of the context should be kept", mockClient.deadlines)
+	}
+}
@@ -0,0 +1,144 @@
+package client
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// GqlError is an error returned in the `errors` of a GraphQL response
+type GqlError struct {
+	Message    string                 `json:"message"`
+	Path       []interface{}          `json:"path,omitempty"`
+	Extensions map[string]interface{} `json:"extensions,omitempty"`
+}
+
+func (e GqlError) Error() string {
+	return e.Message
+}
+
+// GqlResponse is the full response to a GraphQL operation, including the
+// `extensions` services use for tracing, query cost and deprecation warnings
+// and the status code and headers of the lambda response
+type GqlResponse struct {
+	Data       map[string]interface{} `json:"data"`
+	Errors     []GqlError             `json:"errors"`
+	Extensions map[string]interface{} `json:"extensions"`
+	StatusCode int                    `json:"-"`
+	Headers    http.Header            `json:"-"`
+}
+
+// Err returns the first GraphQL error, if any, as a GqlError
+func (r *GqlResponse) Err() error {
+	if len(r.Errors) == 0 {
+		return nil
+	}
+	return r.Errors[0]
+}
+
+// Decode decodes the data into a typed response, see DecodeData
+func (r *GqlResponse) Decode(out interface{}) error {
+	return DecodeData(&r.Data, out)
+}
+
+// Cost returns the `cost` extension, which services use to report the
+// complexity of the query and the remaining budget
+func (r *GqlResponse) Cost() (map[string]interface{}, bool) {
+	cost, ok := r.Extensions["cost"].(map[string]interface{})
+	return cost, ok
+}
+
+// ServerDuration returns how long the service took to resolve the operation,
+// from the Apollo `tracing` extension or the Server-Timing header
+func (r *GqlResponse) ServerDuration() (time.Duration, bool) {
+	if tracing, ok := r.Extensions["tracing"].(map[string]interface{}); ok {
+		if nanoseconds, ok := tracing["duration"].(float64); ok {
+			return time.Duration(nanoseconds), true
+		}
+	}
+	return serverTimingDuration(strings.Join(r.Headers.Values("Server-Timing"), ","))
+}
+
+// Deprecations returns the deprecation warnings in the `deprecations` or
+// `warnings` extensions, given either as strings or as objects with a
+// message
+func (r *GqlResponse) Deprecations() []string {
+	var messages []string
+	for _, key := range []string{"deprecations", "warnings"} {
+		list, _ := r.Extensions[key].([]interface{})
+		for _, item := range list {
+			switch item := item.(type) {
+			case string:
+				messages = append(messages, item)
+			case map[string]interface{}:
+				if message, ok := item["message"].(string); ok {
+					messages = append(messages, message)
+				}
+			}
+		}
+	}
+	return messages
+}
+
+// serverTimingDuration reads the `dur` of the `total` metric of a
+// Server-Timing header, in milliseconds. Other metrics may overlap, so without
+// a total the longest one is used.
+func serverTimingDuration(header string) (time.Duration, bool) {
+	var longest float64
+	found := false
+	for _, metric := range strings.Split(header, ",") {
+		params := strings.Split(metric, ";")
+		for _, param := range params[1:] {
+			name, value, ok := strings.Cut(strings.TrimSpace(param), "=")
+			if !ok || name != "dur" {
+				continue
+			}
+			milliseconds, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				continue
+			}
+			if strings.TrimSpace(params[0]) == "total" {
+				return time.Duration(milliseconds * float64(time.Millisecond)), true
+			}
+			if !found || milliseconds > longest {
+				longest = milliseconds
+				found = true
+			}
+		}
+	}
+	return time.Duration(longest * float64(time.Millisecond)), found
+}
+
+func decodeGqlResponse(statusCode int, headers http.Header, body []byte) (*GqlResponse, error) {
+	response := &GqlResponse{StatusCode: statusCode, Headers: headers}
+	if err := json.Unmarshal(body, response); err != nil {
+		return nil, err
+	}
+	if response.Headers == nil {
+		response.Headers = http.Header{}
+	}
+	return response, nil
+}
+
+// logExtensions logs the query cost and server timing of a response and warns
+// about deprecated fields
+func (c *LambdaClient) logExtensions(operation string, response *GqlResponse) {
+	if c.logger == nil {
+		return
+	}
+	var args []interface{}
+	if cost, ok := response.Cost(); ok {
+		args = append(args, "cost", cost)
+	}
+	if duration, ok := response.ServerDuration(); ok {
+		args = append(args, "serverDuration", duration.Round(time.Millisecond).String())
+	}
+	if len(args) > 0 {
+		c.logger.Debug("phc graphql extensions", append([]interface{}{"operation", operation}, args...)...)
+	}
+	for _, message := range response.Deprecations() {
+		c.logger.Warn("phc deprecated field", "operation", operation, "message", message)
+	}
+}
@@ -0,0 +1,116 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/lambda"
+)
+
+func lambdaResponse(t *testing.T, statusCode int, headers map[string]string, body interface{}) *lambda.InvokeOutput {
+	encodedBody, err := json.Marshal(body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	encoded, err := json.Marshal(responsePayload{StatusCode: statusCode, Headers: headers, Body: string(encodedBody)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &lambda.InvokeOutput{Payload: encoded}
+}
+
+func TestGqlWithResponse(t *testing.T) {
+	mock := MockInvoker{
+		response: lambdaResponse(t, 200, map[string]string{"Server-Timing": "db;dur=12.5, resolve;dur=30"}, map[string]interface{}{
+			"data": map[string]interface{}{"result": true},
+			"extensions": map[string]interface{}{
+				"cost":         map[string]interface{}{"requested": 3.0, "remaining": 997.0},
+				"deprecations": []interface{}{"Module.icon is deprecated, use iconV2"},
+				"warnings":     []interface{}{map[string]interface{}{"message": "Query.modules is deprecated"}},
+			},
+		}),
+	}
+	output := &bytes.Buffer{}
+	client := LambdaClient{invoker: &mock, logger: NewStdLogger(log.New(output, "", 0))}
+
+	response, err := client.GqlWithResponse(context.Background(), "some_lambda:status/some/path", GET_PUBLISHED_APP_TILE_MODULE, nil)
+	if err != nil {
+		t.Fatal("Unexpected error", err)
+	}
+	if response.StatusCode != 200 || response.Data["result"] != true {
+		t.Fatal("Did not decode the response", response)
+	}
+	if cost, ok := response.Cost(); !ok || cost["remaining"] != 997.0 {
+		t.Fatal("Did not expose the cost", response.Extensions)
+	}
+	if duration, ok := response.ServerDuration(); !ok || duration != 30*time.Millisecond {
+		t.Fatal("Did not read the server timing", duration)
+	}
+	if deprecations := response.Deprecations(); len(deprecations) != 2 || deprecations[1] != "Query.modules is deprecated" {
+		t.Fatal("Did not collect deprecations", deprecations)
+	}
+
+	logged := output.String()
+	if !strings.Contains(logged, "phc graphql extensions") || !strings.Contains(logged, `serverDuration="30ms"`) {
+		t.Fatal("Did not log the extensions", logged)
+	}
+	if !strings.Contains(logged, "WARN phc deprecated field") || !strings.Contains(logged, "Module.icon is deprecated") {
+		t.Fatal("Did not warn about deprecated fields", logged)
+	}
+}
+
+func TestServerTimingDuration(t *testing.T) {
+	tests := []struct {
+		header   string
+		duration time.Duration
+		found    bool
+	}{
+		{"db;dur=12.5, total;dur=20, resolve;dur=30", 20 * time.Millisecond, true},
+		{"db;dur=12.5, resolve;desc=\"Resolve\";dur=30", 30 * time.Millisecond, true},
+		{"cache;desc=hit", 0, false},
+		{"", 0, false},
+	}
+	for _, test := range tests {
+		duration, found := serverTimingDuration(test.header)
+		if duration != test.duration || found != test.found {
+			t.Fatal("Unexpected duration", test.header, duration, found)
+		}
+	}
+}
+
+func TestGqlWithResponseErrors(t *testing.T) {
+	mock := MockInvoker{
+		response: lambdaResponse(t, 200, nil, map[string]interface{}{
+			"data":       map[string]interface{}{"partial": true},
+			"errors":     []interface{}{map[string]interface{}{"message": "Not allowed", "path": []interface{}{"secret"}, "extensions": map[string]interface{}{"code": "FORBIDDEN"}}},
+			"extensions": map[string]interface{}{"tracing": map[string]interface{}{"duration": 5e6}},
+		}),
+	}
+	client := LambdaClient{invoker: &mock}
+
+	response, err := client.GqlWithResponse(context.Background(), "some_lambda:status/some/path", GET_PUBLISHED_APP_TILE_MODULE, nil)
+	if err == nil || err.Error() != "Not allowed" {
+		t.Fatal("Expected the GraphQL error", err)
+	}
+	var gqlErr GqlError
+	if !errors.As(err, &gqlErr) || gqlErr.Extensions["code"] != "FORBIDDEN" {
+		t.Fatal("Expected a GqlError", err)
+	}
+	if response == nil || response.Data["partial"] != true {
+		t.Fatal("The response should be returned with its errors", response)
+	}
+	if duration, ok := response.ServerDuration(); !ok || duration != 5*time.Millisecond {
+		t.Fatal("Did not read the tracing extension", duration)
+	}
+
+	_, err = client.Gql("some_lambda:status/some/path", GET_PUBLISHED_APP_TILE_MODULE, nil)
+	if err == nil || err.Error() != "Not allowed" {
+		t.Fatal("Gql should still return the first error", err)
+	}
+}
@@ -1,12 +1,40 @@
 package client
 
+import "context"
+
 type MockClient struct {
 	hasBeenCalled bool
 	response      *map[string]interface{}
 	error         error
+	// operationErrors fail specific operations instead of returning response
+	operationErrors map[string]error
+	// operations and variables record every call in order
+	operations []string
+	variables  []map[string]interface{}
 }
 
 func (m *MockClient) Gql(url string, operation string, variables map[string]interface{}) (*map[string]interface{}, error) {
 	m.hasBeenCalled = true
+	m.operations = append(m.operations, operation)
+	m.variables = append(m.variables, variables)
+	if err, ok := m.operationErrors[operation]; ok {
+		return nil, err
+	}
 	return m.response, m.error
 }
+
+func (m *MockClient) GqlWithContext(ctx context.Context, url string, operation string, variables map[string]interface{}, options ...GqlOption) (*map[string]interface{}, error) {
+	return m.Gql(url, operation, variables)
+}
+
+func (m *MockClient) GqlWithResponse(ctx context.Context, url string, operation string, variables map[string]interface{}, options ...GqlOption) (*GqlResponse, error) {
+	data, err := m.Gql(url, operation, variables)
+	if err != nil {
+		return nil, err
+	}
+	response := &GqlResponse{StatusCode: 200}
+	if data != nil {
+		response.Data = *data
+	}
+	return response, nil
+}
@@ -0,0 +1,208 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"strings"
+)
+
+// Querier runs GraphQL operations against a single service. Generated
+// operation functions accept any Querier, such as a MarketplaceClient or an
+// AppStoreClient.
+type Querier interface {
+	GqlWithContext(ctx context.Context, query string, variables map[string]interface{}, options ...GqlOption) (*map[string]interface{}, error)
+}
+
+// StructToVariables converts a variables struct to the map sent with an
+// operation, using the struct's json tags. Optional fields are sent only when
+// they were set, see Optional. Uploads are kept as they are so they can be
+// sent as files.
+func StructToVariables(variables interface{}) (map[string]interface{}, error) {
+	resolved, _ := resolveOptionals(reflect.ValueOf(variables))
+	normalized, err := normalizeVariables(resolved)
+	if err != nil {
+		return nil, err
+	}
+	result, _ := normalized.(map[string]interface{})
+	return result, nil
+}
+
+// normalizeVariables converts the values left by resolveOptionals to the
+// plain values encoding/json decodes, except for uploads
+func normalizeVariables(value interface{}) (interface{}, error) {
+	switch value := value.(type) {
+	case *Upload:
+		return value, nil
+	case map[string]interface{}:
+		for k, v := range value {
+			normalized, err := normalizeVariables(v)
+			if err != nil {
+				return nil, err
+			}
+			value[k] = normalized
+		}
+		return value, nil
+	case []interface{}:
+		for i, v := range value {
+			normalized, err := normalizeVariables(v)
+			if err != nil {
+				return nil, err
+			}
+			value[i] = normalized
+		}
+		return value, nil
+	}
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	var result interface{}
+	err = json.Unmarshal(encoded, &result)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// resolveOptionals replaces the Optional values in structs, maps and slices
+// with their value, reporting unset ones so they can be left out. Other
+// values are kept as they are for encoding/json.
+func resolveOptionals(value reflect.Value) (interface{}, bool) {
+	if !value.IsValid() {
+		return nil, true
+	}
+	switch upload := value.Interface().(type) {
+	case Upload:
+		return &upload, true
+	case *Upload:
+		if upload != nil {
+			return upload, true
+		}
+	}
+	if optional, ok := value.Interface().(optionalValue); ok {
+		inner, set := optional.variableValue()
+		if !set {
+			return nil, false
+		}
+		return resolveOptionals(reflect.ValueOf(inner))
+	}
+	if _, ok := value.Interface().(json.Marshaler); ok {
+		return value.Interface(), true
+	}
+
+	switch value.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if value.IsNil() {
+			return nil, true
+		}
+		return resolveOptionals(value.Elem())
+	case reflect.Struct:
+		fields := map[string]interface{}{}
+		resolveFields(value, fields)
+		return fields, true
+	case reflect.Map:
+		if value.IsNil() || value.Type().Key().Kind() != reflect.String {
+			return value.Interface(), true
+		}
+		entries := make(map[string]interface{}, value.Len())
+		iter := value.MapRange()
+		for iter.Next() {
+			if entry, ok := resolveOptionals(iter.Value()); ok {
+				entries[iter.Key().String()] = entry
+			}
+		}
+		return entries, true
+	case reflect.Slice, reflect.Array:
+		if value.Kind() == reflect.Slice && (value.IsNil() || value.Type().Elem().Kind() == reflect.Uint8) {
+			return value.Interface(), true
+		}
+		items := make([]interface{}, value.Len())
+		for i := range items {
+			// unset items can't be left out of a list, they are sent as null
+			items[i], _ = resolveOptionals(value.Index(i))
+		}
+		return items, true
+	default:
+		return value.Interface(), true
+	}
+}
+
+// resolveFields follows the encoding/json rules for names, `-`, omitempty
+// and embedded structs
+func resolveFields(value reflect.Value, fields map[string]interface{}) {
+	for i := 0; i < value.NumField(); i++ {
+		field := value.Type().Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, options, _ := strings.Cut(tag, ",")
+		if field.Anonymous && name == "" && field.Type.Kind() == reflect.Struct {
+			resolveFields(value.Field(i), fields)
+			continue
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		if strings.Contains(","+options+",", ",omitempty,") && isEmptyValue(value.Field(i)) {
+			continue
+		}
+		if resolved, ok := resolveOptionals(value.Field(i)); ok {
+			fields[name] = resolved
+		}
+	}
+}
+
+// isEmptyValue matches the values encoding/json leaves out for omitempty
+func isEmptyValue(value reflect.Value) bool {
+	switch value.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return value.Len() == 0
+	case reflect.Interface, reflect.Ptr:
+		return value.IsNil()
+	case reflect.Struct:
+		return false
+	}
+	return value.IsZero()
+}
+
+// DecodeData decodes the data returned by Gql into a typed response
+func DecodeData(data *map[string]interface{}, out interface{}) error {
+	if data == nil {
+		return nil
+	}
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(encoded, out)
+}
+
+// ServiceClient binds a LambdaClient to the GraphQL endpoint of a single
+// service, so it can be passed to generated operation functions
+type ServiceClient struct {
+	graphqlUrl string
+	client     graphqlClient
+}
+
+// Service returns a client for the GraphQL endpoint at uri, for example
+// `marketplace-service:deployed/v1/marketplace/authenticated/graphql`
+func (c *LambdaClient) Service(uri string) *ServiceClient {
+	return &ServiceClient{graphqlUrl: uri, client: c}
+}
+
+func (self *ServiceClient) Gql(query string, variables map[string]interface{}) (*map[string]interface{}, error) {
+	return self.client.Gql(self.graphqlUrl, query, variables)
+}
+
+func (self *ServiceClient) GqlWithContext(ctx context.Context, query string, variables map[string]interface{}, options ...GqlOption) (*map[string]interface{}, error) {
+	return self.client.GqlWithContext(ctx, self.graphqlUrl, query, variables, options...)
+}
+
+func (self *ServiceClient) GqlWithResponse(ctx context.Context, query string, variables map[string]interface{}, options ...GqlOption) (*GqlResponse, error) {
+	return self.client.GqlWithResponse(ctx, self.graphqlUrl, query, variables, options...)
+}
@@ -0,0 +1,139 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/lambda"
+)
+
+func TestServiceClient(t *testing.T) {
+	mock := MockInvoker{
+		response: &lambda.InvokeOutput{
+			Payload: []byte("{ \"body\": \"{ \\\"data\\\": { \\\"myModule\\\": { \\\"title\\\": \\\"test title\\\", \\\"source\\\": { \\\"id\\\": \\\"tile\\\" } } } }\"}"),
+		},
+	}
+	client := &LambdaClient{invoker: &mock}
+
+	res, err := GetPublishedModule(context.Background(), client.Service("some_lambda:status/some/path"), GetPublishedModuleVariables{
+		Id:      "some_module_id",
+		Version: Some("1.0.0"),
+	})
+	if err != nil {
+		t.Fatal("Unexpected error", err)
+	}
+	if *mock.payload.FunctionName != "some_lambda:status" {
+		t.Fatal("Did not use the service uri", *mock.payload.FunctionName)
+	}
+	if res.MyModule == nil || res.MyModule.Title != "test title" || res.MyModule.Source.Id != "tile" {
+		t.Fatal("Did not decode the response", res.MyModule)
+	}
+
+	var sent payload
+	if err := json.Unmarshal(mock.payload.Payload, &sent); err != nil {
+		t.Fatal("Could not parse payload", err)
+	}
+	var body struct {
+		Variables map[string]interface{}
+	}
+	if err := json.Unmarshal([]byte(sent.Body), &body); err != nil {
+		t.Fatal("Could not parse body", err)
+	}
+	if body.Variables["id"] != "some_module_id" || body.Variables["version"] != "1.0.0" {
+		t.Fatal("Did not send the variables", body.Variables)
+	}
+}
+
+func TestStructToVariables(t *testing.T) {
+	variables, err := StructToVariables(GetPublishedModuleVariables{Id: "some_module_id"})
+	if err != nil {
+		t.Fatal("Unexpected error", err)
+	}
+	if variables["id"] != "some_module_id" {
+		t.Fatal("Did not convert variables", variables)
+	}
+	if _, ok := variables["version"]; ok {
+		t.Fatal("Unset optional variables should be left out", variables)
+	}
+}
+
+func TestStructToVariablesOptional(t *testing.T) {
+	variables, err := StructToVariables(EditAppStoreListingVariables{
+		Id: "app",
+		Edits: EditWebAppInput{
+			Name:  Some("New name"),
+			Image: Null[string](),
+		},
+	})
+	if err != nil {
+		t.Fatal("Unexpected error", err)
+	}
+	edits := variables["edits"].(map[string]interface{})
+	if edits["name"] != "New name" {
+		t.Fatal("Did not send the set value", edits)
+	}
+	if image, ok := edits["image"]; !ok || image != nil {
+		t.Fatal("Did not send the explicit null", edits)
+	}
+	if _, ok := edits["url"]; ok {
+		t.Fatal("Unset fields should be left out", edits)
+	}
+	if len(edits) != 2 {
+		t.Fatal("Unexpected fields", edits)
+	}
+}
+
+func TestStructToVariablesTags(t *testing.T) {
+	type Embedded struct {
+		Page Optional[int] `json:"page"`
+	}
+	type variables struct {
+		Embedded
+		Ids      []Optional[string] `json:"ids"`
+		Skipped  string             `json:"-"`
+		Empty    []string           `json:"empty,omitempty"`
+		Category ModuleCategory     `json:"category"`
+		Untagged bool
+	}
+	result, err := StructToVariables(variables{
+		Embedded: Embedded{Page: Some(2)},
+		Ids:      []Optional[string]{Some("a"), {}},
+		Skipped:  "skipped",
+		Empty:    []string{},
+		Category: ModuleCategoryAppTile,
+	})
+	if err != nil {
+		t.Fatal("Unexpected error", err)
+	}
+	expected := `{"Untagged":false,"category":"APP_TILE","ids":["a",null],"page":2}`
+	encoded, _ := json.Marshal(result)
+	if string(encoded) != expected {
+		t.Fatal("Unexpected variables", string(encoded))
+	}
+}
+
+func TestOptionalJSON(t *testing.T) {
+	var decoded struct {
+		Set     Optional[string] `json:"set"`
+		Null    Optional[string] `json:"null"`
+		Missing Optional[string] `json:"missing"`
+	}
+	if err := json.Unmarshal([]byte(`{"set": "value", "null": null}`), &decoded); err != nil {
+		t.Fatal("Unexpected error", err)
+	}
+	if value, ok := decoded.Set.Get(); !ok || value != "value" {
+		t.Fatal("Did not decode the value", decoded.Set)
+	}
+	if !decoded.Null.IsNull() {
+		t.Fatal("Did not decode the null", decoded.Null)
+	}
+	if decoded.Missing.IsSet() {
+		t.Fatal("Missing fields should be unset", decoded.Missing)
+	}
+
+	id := "parent"
+	if value, _ := FromPointer(&id).Get(); value != "parent" || FromPointer[string](nil).IsSet() {
+		t.Fatal("Did not convert pointers")
+	}
+}
@@ -0,0 +1,194 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Upload is the value of an `Upload` scalar variable. Operations with uploads
+// are sent as a GraphQL multipart request
+// (https://github.com/jaydenseric/graphql-multipart-request-spec) through Do,
+// with the files as parts of the body.
+type Upload struct {
+	File        io.Reader
+	FileName    string
+	ContentType string
+}
+
+// MarshalJSON writes the file name, for logs and plans. Multipart requests
+// send null in place of the upload as the spec requires.
+func (u Upload) MarshalJSON() ([]byte, error) {
+	return json.Marshal(u.FileName)
+}
+
+type uploadPart struct {
+	upload *Upload
+	paths  []string
+}
+
+// extractUploads replaces the uploads in variables with null, returning them
+// with the object paths they were found at
+func extractUploads(variables map[string]interface{}) (map[string]interface{}, []*uploadPart) {
+	var parts []*uploadPart
+	byReader := map[interface{}]*uploadPart{}
+	var walk func(value interface{}, path string) interface{}
+	walk = func(value interface{}, path string) interface{} {
+		switch value := value.(type) {
+		case Upload:
+			return walk(&value, path)
+		case *Upload:
+			if value == nil {
+				return nil
+			}
+			// the same reader can only be read once, so it is sent once and
+			// mapped to every path it is used at
+			if value.File != nil && reflect.TypeOf(value.File).Comparable() {
+				if part, ok := byReader[value.File]; ok {
+					part.paths = append(part.paths, path)
+					return nil
+				}
+			}
+			part := &uploadPart{upload: value, paths: []string{path}}
+			if value.File != nil && reflect.TypeOf(value.File).Comparable() {
+				byReader[value.File] = part
+			}
+			parts = append(parts, part)
+			return nil
+		case map[string]interface{}:
+			copied := make(map[string]interface{}, len(value))
+			for k, v := range value {
+				copied[k] = walk(v, path+"."+k)
+			}
+			return copied
+		case []interface{}:
+			copied := make([]interface{}, len(value))
+			for i, v := range value {
+				copied[i] = walk(v, path+"."+strconv.Itoa(i))
+			}
+			return copied
+		default:
+			return value
+		}
+	}
+	replaced, _ := walk(variables, "variables").(map[string]interface{})
+	return replaced, parts
+}
+
+// hasUploads reports whether any variable is an Upload
+func hasUploads(variables map[string]interface{}) bool {
+	_, parts := extractUploads(variables)
+	return len(parts) > 0
+}
+
+// writeMultipartRequest encodes the operations, map and file parts
+func writeMultipartRequest(w io.Writer, query string, operationName string, variables map[string]interface{}) (string, error) {
+	variables, parts := extractUploads(variables)
+	writer := multipart.NewWriter(w)
+
+	operation := map[string]interface{}{"query": query, "variables": variables}
+	if operationName != "" {
+		operation["operationName"] = operationName
+	}
+	operations, err := json.Marshal(operation)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal GraphQL operations: %w", err)
+	}
+	if err := writer.WriteField("operations", string(operations)); err != nil {
+		return "", err
+	}
+	fileMap := make(map[string][]string, len(parts))
+	for i, part := range parts {
+		fileMap[strconv.Itoa(i)] = part.paths
+	}
+	encodedMap, err := json.Marshal(fileMap)
+	if err != nil {
+		return "", err
+	}
+	if err := writer.WriteField("map", string(encodedMap)); err != nil {
+		return "", err
+	}
+
+	for i, part := range parts {
+		if part.upload.File == nil {
+			return "", fmt.Errorf("upload at %s has no file", strings.Join(part.paths, ", "))
+		}
+		fileName := part.upload.FileName
+		if fileName == "" {
+			fileName = strconv.Itoa(i)
+		}
+		contentType := part.upload.ContentType
+		if contentType == "" {
+			contentType = "application/octet-stream"
+		}
+		header := textproto.MIMEHeader{}
+		header.Set("Content-Disposition", fmt.Sprintf(`form-data; name="%d"; filename="%s"`, i, escapeQuotes(fileName)))
+		header.Set("Content-Type", contentType)
+		file, err := writer.CreatePart(header)
+		if err != nil {
+			return "", err
+		}
+		if _, err := io.Copy(file, part.upload.File); err != nil {
+			return "", fmt.Errorf("failed to read upload %s: %w", fileName, err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		return "", err
+	}
+	return writer.FormDataContentType(), nil
+}
+
+var quoteEscaper = strings.NewReplacer("\\", "\\\\", `"`, "\\\"")
+
+func escapeQuotes(s string) string {
+	return quoteEscaper.Replace(s)
+}
+
+// gqlUpload sends an operation with uploads as a multipart request
+func (c *LambdaClient) gqlUpload(ctx context.Context, uri string, query string, operation operationDefinition, variables map[string]interface{}) (*GqlResponse, error) {
+	body := &bytes.Buffer{}
+	contentType, err := writeMultipartRequest(body, query, operation.Name, variables)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, uri, body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", contentType)
+	if operation.Kind == "mutation" {
+		key, ok := IdempotencyKeyFromContext(ctx)
+		if !ok {
+			key, err = NewIdempotencyKey()
+			if err != nil {
+				return nil, err
+			}
+		}
+		req.Header.Set(IDEMPOTENCY_KEY_HEADER, key)
+	}
+
+	// The multipart body doesn't hold the operation name where Do looks for it
+	resp, err := c.do(req, operation.label())
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	responseData, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	response, err := decodeGqlResponse(resp.StatusCode, resp.Header, responseData)
+	if err != nil && resp.StatusCode >= 400 {
+		return nil, fmt.Errorf("upload failed with status %d: %w", resp.StatusCode, err)
+	}
+	return response, err
+}
@@ -0,0 +1,170 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/lambda"
+)
+
+const UPLOAD_MUTATION = `mutation Upload($input: UploadInput!, $files: [Upload!]!) { upload(input: $input, files: $files) { id } }`
+
+func readMultipartPayload(t *testing.T, input *lambda.InvokeInput) (payload, map[string][]byte, map[string]string) {
+	var sent payload
+	if err := json.Unmarshal(input.Payload, &sent); err != nil {
+		t.Fatal("Could not parse payload", err)
+	}
+	body := []byte(sent.Body)
+	if sent.IsBase64Encoded {
+		decoded, err := base64.StdEncoding.DecodeString(sent.Body)
+		if err != nil {
+			t.Fatal("Could not decode body", err)
+		}
+		body = decoded
+	}
+	mediaType, params, err := mime.ParseMediaType(sent.Headers["Content-Type"])
+	if err != nil || mediaType != "multipart/form-data" {
+		t.Fatal("Expected a multipart content type", sent.Headers)
+	}
+	parts := map[string][]byte{}
+	fileNames := map[string]string{}
+	reader := multipart.NewReader(bytes.NewReader(body), params["boundary"])
+	for {
+		part, err := reader.NextPart()
+		if err != nil {
+			break
+		}
+		data, _ := ioutil.ReadAll(part)
+		parts[part.FormName()] = data
+		fileNames[part.FormName()] = part.FileName()
+	}
+	return sent, parts, fileNames
+}
+
+func TestGqlUpload(t *testing.T) {
+	mock := MockInvoker{
+		response: &lambda.InvokeOutput{
+			Payload: []byte(`{ "statusCode": 200, "body": "{ \"data\": { \"upload\": { \"id\": \"file\" } } }"}`),
+		},
+	}
+	client := LambdaClient{invoker: &mock}
+
+	icon := &Upload{File: bytes.NewReader([]byte{0x89, 'P', 'N', 'G', 0xff}), FileName: "icon.png", ContentType: "image/png"}
+	res, err := client.GqlWithContext(WithIdempotencyKey(context.Background(), "key"), "some-service:deployed/graphql", UPLOAD_MUTATION, map[string]interface{}{
+		"input": map[string]interface{}{"name": "icon", "icon": icon},
+		"files": []interface{}{icon, Upload{File: bytes.NewBufferString("text"), FileName: "notes.txt"}},
+	})
+	if err != nil {
+		t.Fatal("Unexpected error", err)
+	}
+	if (*res)["upload"].(map[string]interface{})["id"] != "file" {
+		t.Fatal("Did not decode the response", res)
+	}
+
+	sent, parts, fileNames := readMultipartPayload(t, mock.payload)
+	if !sent.IsBase64Encoded {
+		t.Fatal("Binary bodies should be base64 encoded")
+	}
+	if sent.Path != "/graphql" || sent.HttpMethod != "POST" {
+		t.Fatal("Unexpected request", sent.Path, sent.HttpMethod)
+	}
+	if sent.Headers[IDEMPOTENCY_KEY_HEADER] != "key" {
+		t.Fatal("Did not send the idempotency key", sent.Headers)
+	}
+	if _, ok := sent.Headers["content-type"]; ok {
+		t.Fatal("The default content type should be replaced", sent.Headers)
+	}
+
+	var operations struct {
+		Query     string
+		Variables map[string]interface{}
+	}
+	if err := json.Unmarshal(parts["operations"], &operations); err != nil {
+		t.Fatal("Could not parse operations", err)
+	}
+	if operations.Query != UPLOAD_MUTATION {
+		t.Fatal("Did not send the query", operations.Query)
+	}
+	input := operations.Variables["input"].(map[string]interface{})
+	if input["name"] != "icon" || input["icon"] != nil {
+		t.Fatal("Uploads should be sent as null", input)
+	}
+	if files := operations.Variables["files"].([]interface{}); len(files) != 2 || files[0] != nil || files[1] != nil {
+		t.Fatal("Uploads should be sent as null", files)
+	}
+
+	var fileMap map[string][]string
+	if err := json.Unmarshal(parts["map"], &fileMap); err != nil {
+		t.Fatal("Could not parse map", err)
+	}
+	if len(fileMap) != 2 || len(fileMap["0"]) != 2 || fileMap["1"][0] != "variables.files.1" {
+		t.Fatal("Unexpected map", fileMap)
+	}
+	if !bytes.Equal(parts["0"], []byte{0x89, 'P', 'N', 'G', 0xff}) || fileNames["0"] != "icon.png" {
+		t.Fatal("Did not send the icon", parts["0"], fileNames["0"])
+	}
+	if string(parts["1"]) != "text" || fileNames["1"] != "notes.txt" {
+		t.Fatal("Did not send the notes", string(parts["1"]))
+	}
+	if stats := client.Stats(); len(stats) != 1 || stats[0].Labels.Operation != "Upload" {
+		t.Fatal("Did not label the upload with its operation", stats)
+	}
+}
+
+func TestGqlUploadError(t *testing.T) {
+	mock := MockInvoker{
+		response: &lambda.InvokeOutput{
+			Payload: []byte(`{ "statusCode": 413, "body": "too large"}`),
+		},
+	}
+	client := LambdaClient{invoker: &mock}
+	_, err := client.Gql("some-service:deployed/graphql", UPLOAD_MUTATION, map[string]interface{}{
+		"files": []interface{}{Upload{File: bytes.NewBufferString("text")}},
+	})
+	if err == nil || err.Error()[:30] != "upload failed with status 413:" {
+		t.Fatal("Expected the status in the error", err)
+	}
+}
+
+func TestStructToVariablesKeepsUploads(t *testing.T) {
+	type variables struct {
+		File     Upload           `json:"file"`
+		Optional Optional[Upload] `json:"optional"`
+	}
+	file := bytes.NewBufferString("text")
+	result, err := StructToVariables(variables{File: Upload{File: file}, Optional: Some(Upload{File: file})})
+	if err != nil {
+		t.Fatal("Unexpected error", err)
+	}
+	if upload, ok := result["file"].(*Upload); !ok || upload.File != file {
+		t.Fatal("Did not keep the upload", result)
+	}
+	if _, ok := result["optional"].(*Upload); !ok {
+		t.Fatal("Did not keep the optional upload", result)
+	}
+}
+
+func TestDoBase64Response(t *testing.T) {
+	mock := MockInvoker{
+		response: &lambda.InvokeOutput{
+			Payload: []byte(`{ "statusCode": 200, "isBase64Encoded": true, "body": "` + base64.StdEncoding.EncodeToString([]byte{0, 1, 2}) + `"}`),
+		},
+	}
+	client := LambdaClient{invoker: &mock}
+	req, _ := http.NewRequest("GET", "some-service:deployed/file", nil)
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatal("Unexpected error", err)
+	}
+	body, _ := ioutil.ReadAll(resp.Body)
+	if !bytes.Equal(body, []byte{0, 1, 2}) {
+		t.Fatal("Did not decode the body", body)
+	}
+}
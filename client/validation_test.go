@@ -0,0 +1,92 @@
+package client
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/lifeomic/phc-sdk-go/graphql"
+)
+
+func loadValidator(t *testing.T, path string) *graphql.Validator {
+	validator, err := graphql.LoadValidator(path)
+	if err != nil {
+		t.Fatal("Could not load schema snapshot", path, err)
+	}
+	return validator
+}
+
+// TestOperationsMatchSchema only proves the operations agree with the
+// snapshots, which are maintained by hand until they are replaced with
+// introspection results, see the header of each snapshot
+func TestOperationsMatchSchema(t *testing.T) {
+	marketplace := loadValidator(t, "../schema/marketplace.graphql")
+	appStore := loadValidator(t, "../schema/app-store.graphql")
+
+	operations := []struct {
+		validator *graphql.Validator
+		name      string
+		query     string
+	}{
+		{marketplace, "GET_PUBLISHED_APP_TILE_MODULE", GET_PUBLISHED_APP_TILE_MODULE},
+		{marketplace, "CREATE_DRAFT_MODULE", CREATE_DRAFT_MODULE},
+		{marketplace, "SET_APP_TILE", SET_APP_TILE},
+		{marketplace, "PUBLISH_MODULE", PUBLISH_MODULE},
+		{marketplace, "START_IMAGE_UPLOAD", START_IMAGE_UPLOAD},
+		{marketplace, "FINALIZE_IMAGE_UPLOAD", FINALIZE_IMAGE_UPLOAD},
+		{marketplace, "LIST_MODULES", LIST_MODULES},
+		{marketplace, "GET_DRAFT_MODULE", GET_DRAFT_MODULE},
+		{marketplace, "LIST_DRAFT_MODULES", LIST_DRAFT_MODULES},
+		{marketplace, "UPDATE_DRAFT_MODULE", UPDATE_DRAFT_MODULE},
+		{marketplace, "DELETE_DRAFT_MODULE", DELETE_DRAFT_MODULE},
+		{marketplace, "DELETE_MODULE", DELETE_MODULE},
+		{marketplace, "LIST_MODULE_VERSIONS", LIST_MODULE_VERSIONS},
+		{marketplace, "GET_MODULE_STATUS", GET_MODULE_STATUS},
+		{marketplace, "REORDER_DRAFT_MODULE_IMAGES", REORDER_DRAFT_MODULE_IMAGES},
+		{marketplace, "REMOVE_DRAFT_MODULE_IMAGE", REMOVE_DRAFT_MODULE_IMAGE},
+		{appStore, "GET_APP_STORE_LISTING", GET_APP_STORE_LISTING},
+		{appStore, "DELETE_APP_STORE_LISTING", DELETE_APP_STORE_LISTING},
+		{appStore, "CREATE_APP_STORE_LISTING", CREATE_APP_STORE_LISTING},
+		{appStore, "EDIT_APP_STORE_LISTING", EDIT_APP_STORE_LISTING},
+	}
+	for _, operation := range operations {
+		if err := operation.validator.ValidateDocument(operation.query); err != nil {
+			t.Errorf("%s does not match the schema: %v", operation.name, err)
+		}
+	}
+}
+
+func TestValidateVariables(t *testing.T) {
+	marketplace := loadValidator(t, "../schema/marketplace.graphql")
+
+	err := marketplace.Validate(FINALIZE_IMAGE_UPLOAD, map[string]interface{}{
+		"input": map[string]string{"id": "upload", "moduleId": "module", "type": "ICON"},
+	})
+	if err != nil {
+		t.Fatal("Unexpected error", err)
+	}
+	err = marketplace.Validate(FINALIZE_IMAGE_UPLOAD, map[string]interface{}{
+		"input": map[string]string{"id": "upload", "moduleId": "module", "type": "POSTER"},
+	})
+	if err == nil || !strings.Contains(err.Error(), "POSTER is not a valid ImageType") {
+		t.Fatal("Expected an invalid enum error", err)
+	}
+	err = marketplace.Validate(GET_PUBLISHED_APP_TILE_MODULE, map[string]interface{}{})
+	if err == nil || !strings.Contains(err.Error(), "must be defined") {
+		t.Fatal("Expected a missing variable error", err)
+	}
+}
+
+func TestGqlValidatesBeforeInvoking(t *testing.T) {
+	mock := MockInvoker{}
+	client := LambdaClient{invoker: &mock}
+	WithValidator(MARKETPLACE_GRAPHQL_URL, loadValidator(t, "../schema/marketplace.graphql"))(&client)
+
+	marketplace := client.Marketplace()
+	_, err := marketplace.Gql(`query { myModule(moduleId: "id") { titel } }`, nil)
+	if err == nil || !strings.Contains(err.Error(), `Cannot query field "titel"`) {
+		t.Fatal("Expected a validation error", err)
+	}
+	if mock.hasBeenCalled {
+		t.Fatal("Invalid operations should not be sent")
+	}
+}
@@ -0,0 +1,236 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Version is a semantic version (https://semver.org) of a marketplace module
+type Version struct {
+	Major      int
+	Minor      int
+	Patch      int
+	Prerelease string
+	Build      string
+}
+
+// VersionBump is the part of a version incremented by Version.Next
+type VersionBump string
+
+const (
+	VERSION_BUMP_PATCH VersionBump = "patch"
+	VERSION_BUMP_MINOR VersionBump = "minor"
+	VERSION_BUMP_MAJOR VersionBump = "major"
+)
+
+var (
+	// ErrVersionExists is returned when publishing a version that is already
+	// published
+	ErrVersionExists = errors.New("version is already published")
+	// ErrVersionNotNewer is returned when publishing a version lower than the
+	// latest published version
+	ErrVersionNotNewer = errors.New("version is lower than the latest published version")
+)
+
+// ParseVersion parses a version such as `1.2.3`, `1.0.0-beta.1` or
+// `1.0.0+build.5`
+func ParseVersion(version string) (Version, error) {
+	invalid := func(reason string) (Version, error) {
+		return Version{}, fmt.Errorf("invalid version %q: %s", version, reason)
+	}
+	var parsed Version
+	rest := version
+	if i := strings.IndexByte(rest, '+'); i != -1 {
+		parsed.Build = rest[i+1:]
+		rest = rest[:i]
+		if !validIdentifiers(parsed.Build, false) {
+			return invalid("invalid build metadata")
+		}
+	}
+	if i := strings.IndexByte(rest, '-'); i != -1 {
+		parsed.Prerelease = rest[i+1:]
+		rest = rest[:i]
+		if !validIdentifiers(parsed.Prerelease, true) {
+			return invalid("invalid pre-release")
+		}
+	}
+	parts := strings.Split(rest, ".")
+	if len(parts) != 3 {
+		return invalid("expected major.minor.patch")
+	}
+	numbers := make([]int, 3)
+	for i, part := range parts {
+		if !isNumeric(part) || (len(part) > 1 && part[0] == '0') {
+			return invalid("version numbers must be non-negative integers without leading zeros")
+		}
+		number, err := strconv.Atoi(part)
+		if err != nil {
+			return invalid(err.Error())
+		}
+		numbers[i] = number
+	}
+	parsed.Major, parsed.Minor, parsed.Patch = numbers[0], numbers[1], numbers[2]
+	return parsed, nil
+}
+
+func validIdentifiers(identifiers string, prerelease bool) bool {
+	for _, identifier := range strings.Split(identifiers, ".") {
+		if identifier == "" {
+			return false
+		}
+		for _, ch := range identifier {
+			if !(ch == '-' || ch >= '0' && ch <= '9' || ch >= 'a' && ch <= 'z' || ch >= 'A' && ch <= 'Z') {
+				return false
+			}
+		}
+		if prerelease && isNumeric(identifier) && len(identifier) > 1 && identifier[0] == '0' {
+			return false
+		}
+	}
+	return true
+}
+
+func isNumeric(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, ch := range s {
+		if ch < '0' || ch > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+func (v Version) String() string {
+	version := fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+	if v.Prerelease != "" {
+		version += "-" + v.Prerelease
+	}
+	if v.Build != "" {
+		version += "+" + v.Build
+	}
+	return version
+}
+
+// Compare returns -1, 0 or 1 when v has a lower, equal or higher precedence
+// than other. Build metadata is ignored.
+func (v Version) Compare(other Version) int {
+	for _, diff := range []int{v.Major - other.Major, v.Minor - other.Minor, v.Patch - other.Patch} {
+		if diff != 0 {
+			return sign(diff)
+		}
+	}
+	return comparePrerelease(v.Prerelease, other.Prerelease)
+}
+
+// comparePrerelease follows the semver rules: a version without pre-release
+// is higher than one with, identifiers are compared one by one, numerically
+// when both are numeric
+func comparePrerelease(a string, b string) int {
+	switch {
+	case a == b:
+		return 0
+	case a == "":
+		return 1
+	case b == "":
+		return -1
+	}
+	as, bs := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(as) && i < len(bs); i++ {
+		if as[i] == bs[i] {
+			continue
+		}
+		aNumeric, bNumeric := isNumeric(as[i]), isNumeric(bs[i])
+		switch {
+		case aNumeric && bNumeric:
+			an, _ := strconv.Atoi(as[i])
+			bn, _ := strconv.Atoi(bs[i])
+			return sign(an - bn)
+		case aNumeric:
+			return -1
+		case bNumeric:
+			return 1
+		default:
+			return sign(strings.Compare(as[i], bs[i]))
+		}
+	}
+	return sign(len(as) - len(bs))
+}
+
+func sign(n int) int {
+	switch {
+	case n < 0:
+		return -1
+	case n > 0:
+		return 1
+	}
+	return 0
+}
+
+// Next returns the version after v. Bumping a pre-release patch gives its
+// release, for example 1.2.0-beta.1 becomes 1.2.0.
+func (v Version) Next(bump VersionBump) (Version, error) {
+	next := Version{Major: v.Major, Minor: v.Minor, Patch: v.Patch}
+	switch bump {
+	case VERSION_BUMP_PATCH:
+		if v.Prerelease == "" {
+			next.Patch++
+		}
+	case VERSION_BUMP_MINOR:
+		if v.Prerelease == "" || v.Patch != 0 {
+			next.Minor++
+			next.Patch = 0
+		}
+	case VERSION_BUMP_MAJOR:
+		if v.Prerelease == "" || v.Minor != 0 || v.Patch != 0 {
+			next.Major++
+			next.Minor = 0
+			next.Patch = 0
+		}
+	default:
+		return Version{}, fmt.Errorf("unknown version bump %q", bump)
+	}
+	return next, nil
+}
+
+// latestVersion returns the highest of the versions, ignoring versions that
+// are not semantic versions
+func latestVersion(versions []string) *Version {
+	var latest *Version
+	for _, version := range versions {
+		parsed, err := ParseVersion(version)
+		if err != nil {
+			continue
+		}
+		if latest == nil || parsed.Compare(*latest) > 0 {
+			latest = &parsed
+		}
+	}
+	return latest
+}
+
+// checkPublishableVersion checks that version is a semantic version that is
+// higher than every published version
+func checkPublishableVersion(published []string, version string) error {
+	candidate, err := ParseVersion(version)
+	if err != nil {
+		return err
+	}
+	for _, existing := range published {
+		if existing == version {
+			return fmt.Errorf("%s: %w", version, ErrVersionExists)
+		}
+	}
+	if latest := latestVersion(published); latest != nil {
+		switch candidate.Compare(*latest) {
+		case 0:
+			return fmt.Errorf("%s has the same precedence as %s: %w", version, latest, ErrVersionExists)
+		case -1:
+			return fmt.Errorf("%s is lower than %s: %w", version, latest, ErrVersionNotNewer)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,140 @@
+package client
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseVersion(t *testing.T) {
+	version, err := ParseVersion("1.2.3-beta.1+build.5")
+	if err != nil {
+		t.Fatal("Unexpected error", err)
+	}
+	if version != (Version{Major: 1, Minor: 2, Patch: 3, Prerelease: "beta.1", Build: "build.5"}) {
+		t.Fatal("Unexpected version", version)
+	}
+	if version.String() != "1.2.3-beta.1+build.5" {
+		t.Fatal("Unexpected string", version.String())
+	}
+	for _, invalid := range []string{"", "1.2", "1.2.3.4", "v1.2.3", "01.2.3", "1.2.3-", "1.2.3-01", "1.2.3+", "1.2.x"} {
+		if _, err := ParseVersion(invalid); err == nil {
+			t.Fatal("Expected an error for", invalid)
+		}
+	}
+}
+
+func TestCompareVersions(t *testing.T) {
+	ordered := []string{"1.0.0-alpha", "1.0.0-alpha.1", "1.0.0-alpha.beta", "1.0.0-beta", "1.0.0-beta.2", "1.0.0-beta.11", "1.0.0-rc.1", "1.0.0", "1.0.1", "1.1.0", "2.0.0"}
+	for i := 1; i < len(ordered); i++ {
+		lower, _ := ParseVersion(ordered[i-1])
+		higher, _ := ParseVersion(ordered[i])
+		if lower.Compare(higher) != -1 || higher.Compare(lower) != 1 {
+			t.Fatal("Expected", ordered[i-1], "to be lower than", ordered[i])
+		}
+	}
+	a, _ := ParseVersion("1.0.0+a")
+	b, _ := ParseVersion("1.0.0+b")
+	if a.Compare(b) != 0 {
+		t.Fatal("Build metadata should be ignored")
+	}
+}
+
+func TestNextVersion(t *testing.T) {
+	cases := []struct {
+		version  string
+		bump     VersionBump
+		expected string
+	}{
+		{"1.2.3", VERSION_BUMP_PATCH, "1.2.4"},
+		{"1.2.3", VERSION_BUMP_MINOR, "1.3.0"},
+		{"1.2.3", VERSION_BUMP_MAJOR, "2.0.0"},
+		{"1.2.3-beta", VERSION_BUMP_PATCH, "1.2.3"},
+		{"1.2.0-beta", VERSION_BUMP_MINOR, "1.2.0"},
+		{"2.0.0-rc.1", VERSION_BUMP_MAJOR, "2.0.0"},
+	}
+	for _, c := range cases {
+		version, _ := ParseVersion(c.version)
+		next, err := version.Next(c.bump)
+		if err != nil {
+			t.Fatal("Unexpected error", err)
+		}
+		if next.String() != c.expected {
+			t.Fatal("Unexpected next version of", c.version, next)
+		}
+	}
+	if _, err := (Version{}).Next("huge"); err == nil {
+		t.Fatal("Expected an error for an unknown bump")
+	}
+}
+
+func TestNextModuleVersion(t *testing.T) {
+	mockClient := MockClient{
+		response: &map[string]interface{}{
+			"myModule": map[string]interface{}{
+				"id": "module-id",
+				"versions": []interface{}{
+					map[string]interface{}{"version": "1.0.0"},
+					map[string]interface{}{"version": "1.10.0"},
+					map[string]interface{}{"version": "not-a-version"},
+					map[string]interface{}{"version": "1.9.2"},
+				},
+			},
+		},
+	}
+	client := MarketplaceClient{client: &mockClient}
+	versions, err := client.ListModuleVersions("module-id")
+	if err != nil || len(versions) != 4 {
+		t.Fatal("Unexpected versions", versions, err)
+	}
+	next, err := client.NextModuleVersion("module-id", VERSION_BUMP_MINOR)
+	if err != nil {
+		t.Fatal("Unexpected error", err)
+	}
+	if next.String() != "1.11.0" {
+		t.Fatal("Unexpected next version", next)
+	}
+
+	mockClient.response = &map[string]interface{}{
+		"myModule": map[string]interface{}{"id": "module-id", "versions": []interface{}{}},
+	}
+	next, err = client.NextModuleVersion("module-id", VERSION_BUMP_PATCH)
+	if err != nil || next.String() != "0.0.1" {
+		t.Fatal("Unexpected first version", next, err)
+	}
+}
+
+func TestPublishNewModuleVersionRefusesOldVersions(t *testing.T) {
+	params := ModuleCreate{Name: "name", Source: &AppTileSource{Id: "app-tile-id"}}
+	for version, expected := range map[string]error{"1.2.0": ErrVersionExists, "1.1.9": ErrVersionNotNewer} {
+		mockClient := MockClient{
+			response: &map[string]interface{}{
+				"myModule": map[string]interface{}{
+					"id": "module-id",
+					"versions": []interface{}{
+						map[string]interface{}{"version": "1.0.0"},
+						map[string]interface{}{"version": "1.2.0"},
+					},
+				},
+			},
+		}
+		client := MarketplaceClient{client: &mockClient}
+		params.Version = version
+		_, err := client.PublishNewModuleVersion("module-id", params)
+		if !errors.Is(err, expected) {
+			t.Fatal("Expected", expected, "got", err)
+		}
+		if len(mockClient.operations) != 1 || mockClient.operations[0] != LIST_MODULE_VERSIONS {
+			t.Fatal("Nothing should be created", mockClient.operations)
+		}
+	}
+
+	mockClient := MockClient{}
+	client := MarketplaceClient{client: &mockClient}
+	params.Version = "latest"
+	if _, err := client.PublishNewModuleVersion("module-id", params); err == nil {
+		t.Fatal("Expected an error for an invalid version")
+	}
+	if mockClient.hasBeenCalled {
+		t.Fatal("Invalid versions should be refused before any request")
+	}
+}
@@ -1,64 +1,76 @@
 package main
 
+//go:generate go run ./phc-codegen --schema=../schema/marketplace.graphql --out=query_gen.go ../query.graphql
+
 import (
+	"context"
 	"encoding/json"
 	"io/ioutil"
 	"log"
 
 	"github.com/alexflint/go-arg"
 	"github.com/lifeomic/phc-sdk-go/client"
-	"github.com/mitchellh/mapstructure"
 )
 
 func main() {
 	var args struct {
-		Query     string `arg:"required"`
+		Query     string `help:"operation document to run, the generated GetPublishedModule is run without it"`
 		Variables string `arg:"required"`
 		Uri       string `arg:"required"`
 		User      string `arg:"required"`
 	}
 	arg.MustParse(&args)
 
-	query, err := ioutil.ReadFile(args.Query)
+	variablesFile, err := ioutil.ReadFile(args.Variables)
 	if err != nil {
 		log.Fatal(err)
 	}
 
-	variablesFile, err := ioutil.ReadFile(args.Variables)
+	phcClient, err := client.BuildClient("lifeomic", args.User, map[string]bool{})
 	if err != nil {
 		log.Fatal(err)
 	}
 
-	var variables map[string]interface{}
+	if args.Query != "" {
+		runQuery(phcClient, args.Uri, args.Query, variablesFile)
+		return
+	}
+
+	var variables GetPublishedModuleVariables
 	err = json.Unmarshal(variablesFile, &variables)
 	if err != nil {
 		log.Fatal(err)
 	}
 
-	phcClient, err := client.BuildClient("lifeomic", args.User, map[string]bool{})
+	resp, err := GetPublishedModule(context.Background(), phcClient.Service(args.Uri), variables)
 	if err != nil {
 		log.Fatal(err)
 	}
 
-	resp, err := phcClient.Gql(args.Uri, string(query), variables)
+	log.Printf("%+v", resp.MyModule)
+}
+
+// runQuery runs any operation document and prints the data it returns
+func runQuery(phcClient *client.LambdaClient, uri string, queryFile string, variablesFile []byte) {
+	query, err := ioutil.ReadFile(queryFile)
 	if err != nil {
 		log.Fatal(err)
 	}
 
-	var module struct {
-		MyModule struct {
-			Description string
-			Title       string
-			Source      struct {
-				Id string
-			}
-		}
+	var variables map[string]interface{}
+	err = json.Unmarshal(variablesFile, &variables)
+	if err != nil {
+		log.Fatal(err)
 	}
 
-	err = mapstructure.Decode(resp, &module)
+	resp, err := phcClient.Gql(uri, string(query), variables)
 	if err != nil {
 		log.Fatal(err)
 	}
 
-	log.Println(module.MyModule)
+	data, err := json.MarshalIndent(resp, "", "  ")
+	if err != nil {
+		log.Fatal(err)
+	}
+	log.Println(string(data))
 }
@@ -0,0 +1,167 @@
+// Command phc-codegen generates typed Go functions for GraphQL operations.
+//
+// It reads a schema snapshot, either SDL or an introspection result, or
+// introspects a running service, validates the operation documents against it
+// and writes a Go file with a function per operation. It is meant to be run
+// through go generate:
+//
+//	//go:generate go run github.com/lifeomic/phc-sdk-go/cmd/phc-codegen --schema=marketplace.graphql operations.graphql
+//
+// The package name defaults to the package go generate runs in and the output
+// file to the first operation document with a _gen.go suffix.
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/alexflint/go-arg"
+	"github.com/lifeomic/phc-sdk-go/client"
+	"github.com/lifeomic/phc-sdk-go/codegen"
+	"github.com/lifeomic/phc-sdk-go/graphql"
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+const CLIENT_IMPORT = "github.com/lifeomic/phc-sdk-go/client"
+
+func main() {
+	var args struct {
+		Schema       string   `help:"schema snapshot, SDL or introspection JSON"`
+		Introspect   string   `help:"introspect the service at this uri instead of reading a snapshot"`
+		User         string   `help:"user to introspect as"`
+		Account      string   `default:"lifeomic" help:"account to introspect in"`
+		SaveSchema   string   `help:"write the schema as SDL to this file"`
+		Package      string   `env:"GOPACKAGE" help:"name of the generated package, defaults to main outside of go generate"`
+		ClientImport string   `help:"import path of the client package, defaults to the phc-sdk-go client outside of package client"`
+		Scalar       []string `help:"map a custom scalar to a Go type, e.g. JSON=map[string]string"`
+		Type         []string `help:"use a Go type declared elsewhere for an enum or input object, e.g. ModuleCategory=ModuleCategory, several mappings follow a single --type"`
+		Out          string   `help:"file to write the generated code to"`
+		Check        bool     `help:"only check that the output is up to date"`
+		Operations   []string `arg:"positional" help:"GraphQL operation documents or glob patterns"`
+	}
+	arg.MustParse(&args)
+	log.SetFlags(0)
+
+	schema, err := loadSchema(args.Schema, args.Introspect, args.Account, args.User)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if args.SaveSchema != "" {
+		err = ioutil.WriteFile(args.SaveSchema, []byte(graphql.FormatSchema(schema)), 0644)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+	if len(args.Operations) == 0 {
+		return
+	}
+
+	sources, err := readOperations(args.Operations)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	config := codegen.Config{
+		Package:      args.Package,
+		ClientImport: args.ClientImport,
+		Scalars:      map[string]string{},
+		Types:        map[string]string{},
+	}
+	if config.Package == "" {
+		config.Package = "main"
+	}
+	if config.ClientImport == "" && config.Package != "client" {
+		config.ClientImport = CLIENT_IMPORT
+	}
+	for _, mapping := range args.Scalar {
+		parts := strings.SplitN(mapping, "=", 2)
+		if len(parts) != 2 {
+			log.Fatalf("Invalid scalar mapping %s", mapping)
+		}
+		config.Scalars[parts[0]] = parts[1]
+	}
+	for _, mapping := range args.Type {
+		parts := strings.SplitN(mapping, "=", 2)
+		if len(parts) != 2 {
+			log.Fatalf("Invalid type mapping %s", mapping)
+		}
+		config.Types[parts[0]] = parts[1]
+	}
+
+	out := args.Out
+	if out == "" {
+		out = strings.TrimSuffix(sources[0].Name, filepath.Ext(sources[0].Name)) + "_gen.go"
+	}
+
+	generated, err := codegen.Generate(schema, sources, config)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Leave the file alone when nothing changed so go generate doesn't touch
+	// it needlessly
+	existing, err := ioutil.ReadFile(out)
+	if err == nil && bytes.Equal(existing, generated) {
+		return
+	}
+	if args.Check {
+		log.Fatalf("%s is out of date, run go generate", out)
+	}
+	err = ioutil.WriteFile(out, generated, 0644)
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+func readOperations(patterns []string) ([]*ast.Source, error) {
+	var sources []*ast.Source
+	for _, pattern := range patterns {
+		files, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, err
+		}
+		if len(files) == 0 {
+			return nil, fmt.Errorf("no operation documents match %s", pattern)
+		}
+		for _, file := range files {
+			raw, err := ioutil.ReadFile(file)
+			if err != nil {
+				return nil, err
+			}
+			sources = append(sources, &ast.Source{Name: file, Input: string(raw)})
+		}
+	}
+	return sources, nil
+}
+
+func loadSchema(path string, uri string, account string, user string) (*ast.Schema, error) {
+	if uri == "" {
+		if path == "" {
+			return nil, errors.New("either --schema or --introspect is required")
+		}
+		return graphql.LoadSchemaFile(path)
+	}
+	if user == "" {
+		user = os.Getenv("USER")
+	}
+	phcClient, err := client.BuildClient(account, user, map[string]bool{})
+	if err != nil {
+		return nil, err
+	}
+	resp, err := phcClient.Gql(uri, graphql.INTROSPECTION_QUERY, nil)
+	if err != nil {
+		return nil, err
+	}
+	raw, err := json.Marshal(resp)
+	if err != nil {
+		return nil, err
+	}
+	return graphql.SchemaFromIntrospection(raw)
+}
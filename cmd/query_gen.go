@@ -0,0 +1,75 @@
+// Code generated by phc-codegen. DO NOT EDIT.
+
+package main
+
+import (
+	"context"
+
+	phc "github.com/lifeomic/phc-sdk-go/client"
+)
+
+// GetPublishedModuleDocument is the query GetPublishedModule
+const GetPublishedModuleDocument = `
+query GetPublishedModule ($id: ID!, $version: String) {
+  myModule(moduleId: $id, version: $version) {
+    title
+    description
+    version
+    source {
+      ... on AppTile {
+        id
+      }
+    }
+    iconV2 {
+      url
+      fileName
+      fileExtension
+    }
+  }
+}
+`
+
+type GetPublishedModuleVariables struct {
+	Id      string               `json:"id"`
+	Version phc.Optional[string] `json:"version"`
+}
+
+type GetPublishedModuleResponse struct {
+	MyModule *GetPublishedModuleMyModule `json:"myModule"`
+}
+
+type GetPublishedModuleMyModule struct {
+	Title       string                            `json:"title"`
+	Description string                            `json:"description"`
+	Version     string                            `json:"version"`
+	Source      GetPublishedModuleMyModuleSource  `json:"source"`
+	IconV2      *GetPublishedModuleMyModuleIconV2 `json:"iconV2"`
+}
+
+type GetPublishedModuleMyModuleSource struct {
+	Id string `json:"id"`
+}
+
+type GetPublishedModuleMyModuleIconV2 struct {
+	Url           string `json:"url"`
+	FileName      string `json:"fileName"`
+	FileExtension string `json:"fileExtension"`
+}
+
+// GetPublishedModule runs the query GetPublishedModule
+func GetPublishedModule(ctx context.Context, client phc.Querier, variables GetPublishedModuleVariables) (*GetPublishedModuleResponse, error) {
+	vars, err := phc.StructToVariables(variables)
+	if err != nil {
+		return nil, err
+	}
+	res, err := client.GqlWithContext(ctx, GetPublishedModuleDocument, vars)
+	if err != nil {
+		return nil, err
+	}
+	var response GetPublishedModuleResponse
+	err = phc.DecodeData(res, &response)
+	if err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
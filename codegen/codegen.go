@@ -0,0 +1,451 @@
+// Package codegen generates typed Go code from GraphQL operation documents
+// validated against a schema snapshot. For every operation it emits the
+// document, a variables struct, a response struct and a function that runs the
+// operation through a client.Querier.
+package codegen
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/formatter"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+	"github.com/vektah/gqlparser/v2/parser"
+	"github.com/vektah/gqlparser/v2/validator"
+)
+
+type Config struct {
+	// Package is the name of the generated package
+	Package string
+	// ClientImport is the import path of the client package. It is empty when
+	// generating into the client package itself.
+	ClientImport string
+	// Scalars maps custom GraphQL scalars to Go types, unmapped scalars are
+	// generated as interface{} except for the Upload scalar of the multipart
+	// request spec
+	Scalars map[string]string
+	// Types maps enums and input objects to Go types declared elsewhere, such
+	// as in the code generated for another schema, instead of generating them
+	Types map[string]string
+}
+
+var builtInScalars = map[string]string{
+	"ID":      "string",
+	"String":  "string",
+	"Int":     "int",
+	"Float":   "float64",
+	"Boolean": "bool",
+}
+
+// ParseOperations parses the sources into a single document and validates it
+// against the schema. Fragments may be shared between sources.
+func ParseOperations(schema *ast.Schema, sources []*ast.Source) (*ast.QueryDocument, error) {
+	document := &ast.QueryDocument{}
+	var errs gqlerror.List
+	for _, source := range sources {
+		parsed, err := parser.ParseQuery(source)
+		if err != nil {
+			errs = append(errs, err.(*gqlerror.Error))
+			continue
+		}
+		document.Operations = append(document.Operations, parsed.Operations...)
+		document.Fragments = append(document.Fragments, parsed.Fragments...)
+	}
+	if len(errs) > 0 {
+		return nil, errs
+	}
+	if errs := validator.Validate(schema, document); len(errs) > 0 {
+		return nil, errs
+	}
+	for _, operation := range document.Operations {
+		if operation.Name == "" {
+			return nil, gqlerror.ErrorPosf(operation.Position, "operations must be named to generate code for them")
+		}
+	}
+	return document, nil
+}
+
+type generator struct {
+	schema   *ast.Schema
+	config   Config
+	document *ast.QueryDocument
+
+	qualifier string
+	body      bytes.Buffer
+	declared  map[string]bool
+	fragments map[string]bool
+	enums     map[string]bool
+	inputs    map[string]bool
+}
+
+// Generate returns gofmt'ed Go source for the operations in the sources
+func Generate(schema *ast.Schema, sources []*ast.Source, config Config) ([]byte, error) {
+	document, err := ParseOperations(schema, sources)
+	if err != nil {
+		return nil, err
+	}
+	g := &generator{
+		schema:    schema,
+		config:    config,
+		document:  document,
+		declared:  map[string]bool{},
+		fragments: map[string]bool{},
+		enums:     map[string]bool{},
+		inputs:    map[string]bool{},
+	}
+	if config.ClientImport != "" {
+		g.qualifier = "phc."
+	}
+
+	for _, operation := range document.Operations {
+		if err := g.operation(operation); err != nil {
+			return nil, err
+		}
+	}
+	g.inputTypes()
+	g.enumTypes()
+
+	out := &bytes.Buffer{}
+	fmt.Fprintf(out, "// Code generated by phc-codegen. DO NOT EDIT.\n\npackage %s\n\n", config.Package)
+	out.WriteString("import (\n\t\"context\"\n")
+	if config.ClientImport != "" {
+		fmt.Fprintf(out, "\n\tphc %q\n", config.ClientImport)
+	}
+	out.WriteString(")\n")
+	out.Write(g.body.Bytes())
+
+	formatted, err := format.Source(out.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("generated invalid code: %w\n%s", err, out.String())
+	}
+	return formatted, nil
+}
+
+func (g *generator) printf(format string, args ...interface{}) {
+	fmt.Fprintf(&g.body, format, args...)
+}
+
+func (g *generator) operation(operation *ast.OperationDefinition) error {
+	name := exported(operation.Name)
+
+	g.printf("\n// %sDocument is the %s %s\n", name, operation.Operation, operation.Name)
+	g.printf("const %sDocument = %s\n", name, quoteDocument(g.operationDocument(operation)))
+
+	hasVariables := len(operation.VariableDefinitions) > 0
+	if hasVariables {
+		g.printf("\ntype %sVariables struct {\n", name)
+		for _, variable := range operation.VariableDefinitions {
+			g.printf("\t%s %s `json:\"%s\"`\n", exported(variable.Variable), g.fieldType(variable.Type), variable.Variable)
+		}
+		g.printf("}\n")
+	}
+
+	responseType := name + "Response"
+	if err := g.selectionStruct(responseType, name, operation.SelectionSet); err != nil {
+		return err
+	}
+
+	variablesParam, variablesArg := "", "nil"
+	if hasVariables {
+		variablesParam = fmt.Sprintf(", variables %sVariables", name)
+		variablesArg = "vars"
+	}
+	g.printf("\n// %s runs the %s %s\n", name, operation.Operation, operation.Name)
+	g.printf("func %s(ctx context.Context, client %sQuerier%s) (*%s, error) {\n", name, g.qualifier, variablesParam, responseType)
+	if hasVariables {
+		g.printf("\tvars, err := %sStructToVariables(variables)\n\tif err != nil {\n\t\treturn nil, err\n\t}\n", g.qualifier)
+		g.printf("\tres, err := client.GqlWithContext(ctx, %sDocument, %s)\n", name, variablesArg)
+	} else {
+		g.printf("\tres, err := client.GqlWithContext(ctx, %sDocument, %s)\n", name, variablesArg)
+	}
+	g.printf("\tif err != nil {\n\t\treturn nil, err\n\t}\n")
+	g.printf("\tvar response %s\n", responseType)
+	g.printf("\terr = %sDecodeData(res, &response)\n\tif err != nil {\n\t\treturn nil, err\n\t}\n", g.qualifier)
+	g.printf("\treturn &response, nil\n}\n")
+	return nil
+}
+
+// operationDocument renders the operation together with every fragment it
+// uses, so each document can be sent on its own
+func (g *generator) operationDocument(operation *ast.OperationDefinition) string {
+	used := map[string]bool{}
+	var collect func(ast.SelectionSet)
+	collect = func(selections ast.SelectionSet) {
+		for _, selection := range selections {
+			switch selection := selection.(type) {
+			case *ast.Field:
+				collect(selection.SelectionSet)
+			case *ast.InlineFragment:
+				collect(selection.SelectionSet)
+			case *ast.FragmentSpread:
+				if !used[selection.Name] {
+					used[selection.Name] = true
+					if fragment := g.document.Fragments.ForName(selection.Name); fragment != nil {
+						collect(fragment.SelectionSet)
+					}
+				}
+			}
+		}
+	}
+	collect(operation.SelectionSet)
+
+	document := &ast.QueryDocument{Operations: ast.OperationList{operation}}
+	for _, fragment := range g.document.Fragments {
+		if used[fragment.Name] {
+			document.Fragments = append(document.Fragments, fragment)
+		}
+	}
+	buffer := &bytes.Buffer{}
+	formatter.NewFormatter(buffer, formatter.WithIndent("  ")).FormatQueryDocument(document)
+	return buffer.String()
+}
+
+type selectedField struct {
+	alias      string
+	definition *ast.FieldDefinition
+	selections ast.SelectionSet
+}
+
+// flatten merges the fields of inline fragments and fragment spreads into
+// the selection set, keeping the order fields were first selected in
+func (g *generator) flatten(selections ast.SelectionSet, fields *[]*selectedField, byAlias map[string]*selectedField) {
+	for _, selection := range selections {
+		switch selection := selection.(type) {
+		case *ast.Field:
+			if existing, ok := byAlias[selection.Alias]; ok {
+				existing.selections = append(existing.selections, selection.SelectionSet...)
+				continue
+			}
+			field := &selectedField{alias: selection.Alias, definition: selection.Definition, selections: selection.SelectionSet}
+			byAlias[selection.Alias] = field
+			*fields = append(*fields, field)
+		case *ast.InlineFragment:
+			g.flatten(selection.SelectionSet, fields, byAlias)
+		case *ast.FragmentSpread:
+			if fragment := g.document.Fragments.ForName(selection.Name); fragment != nil {
+				g.flatten(fragment.SelectionSet, fields, byAlias)
+			}
+		}
+	}
+}
+
+// selectionStruct declares a struct for a selection set, nested structs are
+// named after prefix and the field they are selected in, or after the
+// fragment when the field only selects a fragment spread. Fields selected in
+// fragments on a more specific type are left at their zero value when the
+// object is of a different type.
+func (g *generator) selectionStruct(name string, prefix string, selections ast.SelectionSet) error {
+	var fields []*selectedField
+	g.flatten(selections, &fields, map[string]*selectedField{})
+
+	type nested struct {
+		name       string
+		selections ast.SelectionSet
+	}
+	var nestedTypes []nested
+
+	g.declared[name] = true
+	declaration := &bytes.Buffer{}
+	fmt.Fprintf(declaration, "\ntype %s struct {\n", name)
+	for _, field := range fields {
+		if field.definition == nil {
+			return fmt.Errorf("no definition for field %s of %s", field.alias, name)
+		}
+		var goType string
+		if fragment := g.fragmentSelection(field); fragment != nil {
+			fragmentName := exported(fragment.Name)
+			if !g.fragments[fragment.Name] {
+				if g.declared[fragmentName] {
+					return fmt.Errorf("fragment %s has the name of another generated type", fragment.Name)
+				}
+				g.fragments[fragment.Name] = true
+				g.declared[fragmentName] = true
+				nestedTypes = append(nestedTypes, nested{name: fragmentName, selections: fragment.SelectionSet})
+			}
+			goType = g.outputType(field.definition.Type, fragmentName)
+		} else if len(field.selections) > 0 {
+			nestedName := g.uniqueName(prefix + exported(strings.TrimLeft(field.alias, "_")))
+			g.declared[nestedName] = true
+			nestedTypes = append(nestedTypes, nested{name: nestedName, selections: field.selections})
+			goType = g.outputType(field.definition.Type, nestedName)
+		} else if field.definition.Name == "__typename" {
+			goType = "string"
+		} else {
+			goType = g.outputType(field.definition.Type, "")
+		}
+		fmt.Fprintf(declaration, "\t%s %s `json:\"%s\"`\n", exported(strings.TrimLeft(field.alias, "_")), goType, field.alias)
+	}
+	declaration.WriteString("}\n")
+	g.body.Write(declaration.Bytes())
+
+	for _, n := range nestedTypes {
+		if err := g.selectionStruct(n.name, n.name, n.selections); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// fragmentSelection returns the fragment when the field selects exactly one
+// fragment spread on its own type. Its struct is named after the fragment and
+// declared once, so every field selecting it shares the type.
+func (g *generator) fragmentSelection(field *selectedField) *ast.FragmentDefinition {
+	if len(field.selections) != 1 {
+		return nil
+	}
+	spread, ok := field.selections[0].(*ast.FragmentSpread)
+	if !ok {
+		return nil
+	}
+	fragment := g.document.Fragments.ForName(spread.Name)
+	if fragment == nil || fragment.TypeCondition != field.definition.Type.Name() {
+		return nil
+	}
+	return fragment
+}
+
+func (g *generator) uniqueName(name string) string {
+	candidate := name
+	for i := 2; g.declared[candidate]; i++ {
+		candidate = name + strconv.Itoa(i)
+	}
+	return candidate
+}
+
+// outputType maps a response field type, structName is the struct declared
+// for object fields
+func (g *generator) outputType(t *ast.Type, structName string) string {
+	if t.Elem != nil {
+		return "[]" + g.outputType(t.Elem, structName)
+	}
+	base := structName
+	if base == "" {
+		base = g.namedType(t.NamedType)
+	}
+	if !t.NonNull && pointable(base) {
+		return "*" + base
+	}
+	return base
+}
+
+// fieldType maps the type of a variable or input field. Nullable ones are
+// Optional so callers can choose between leaving them out and sending null.
+func (g *generator) fieldType(t *ast.Type) string {
+	if t.NonNull {
+		return g.inputType(t)
+	}
+	required := *t
+	required.NonNull = true
+	return g.qualifier + "Optional[" + g.inputType(&required) + "]"
+}
+
+// inputType maps a variable or input field type, registering the input
+// objects and enums it refers to
+func (g *generator) inputType(t *ast.Type) string {
+	if t.Elem != nil {
+		return "[]" + g.inputType(t.Elem)
+	}
+	base := g.namedType(t.NamedType)
+	if definition := g.schema.Types[t.NamedType]; definition != nil && definition.Kind == ast.InputObject && !g.inputs[t.NamedType] && g.config.Types[t.NamedType] == "" {
+		g.inputs[t.NamedType] = true
+		for _, field := range definition.Fields {
+			g.inputType(field.Type)
+		}
+	}
+	if !t.NonNull && pointable(base) {
+		return "*" + base
+	}
+	return base
+}
+
+func (g *generator) namedType(name string) string {
+	if goType, ok := builtInScalars[name]; ok {
+		return goType
+	}
+	definition := g.schema.Types[name]
+	if goType, ok := g.config.Types[name]; ok && definition != nil && (definition.Kind == ast.Enum || definition.Kind == ast.InputObject) {
+		return goType
+	}
+	switch {
+	case definition.Kind == ast.Enum:
+		g.enums[name] = true
+		return name
+	case definition.Kind == ast.InputObject:
+		return name
+	case definition.Kind == ast.Scalar:
+		if goType, ok := g.config.Scalars[name]; ok {
+			return goType
+		}
+		if name == "Upload" {
+			return g.qualifier + "Upload"
+		}
+		return "interface{}"
+	default:
+		return "interface{}"
+	}
+}
+
+func (g *generator) inputTypes() {
+	names := sortedKeys(g.inputs)
+	for _, name := range names {
+		definition := g.schema.Types[name]
+		g.printf("\ntype %s struct {\n", name)
+		for _, field := range definition.Fields {
+			g.printf("\t%s %s `json:\"%s\"`\n", exported(field.Name), g.fieldType(field.Type), field.Name)
+		}
+		g.printf("}\n")
+	}
+}
+
+func (g *generator) enumTypes() {
+	for _, name := range sortedKeys(g.enums) {
+		definition := g.schema.Types[name]
+		g.printf("\ntype %s string\n\nconst (\n", name)
+		for _, value := range definition.EnumValues {
+			g.printf("\t%s%s %s = %q\n", name, pascal(value.Name), name, value.Name)
+		}
+		g.printf(")\n")
+	}
+}
+
+// pointable reports whether null has to be represented by a pointer
+func pointable(goType string) bool {
+	return !strings.HasPrefix(goType, "[]") && !strings.HasPrefix(goType, "map[") && goType != "interface{}"
+}
+
+func exported(name string) string {
+	if name == "" {
+		return name
+	}
+	return strings.ToUpper(name[:1]) + name[1:]
+}
+
+// pascal converts enum values such as APP_TILE to AppTile
+func pascal(value string) string {
+	parts := strings.Split(strings.ToLower(value), "_")
+	for i, part := range parts {
+		parts[i] = exported(part)
+	}
+	return strings.Join(parts, "")
+}
+
+func quoteDocument(document string) string {
+	if strings.Contains(document, "`") {
+		return strconv.Quote(document)
+	}
+	return "`\n" + document + "`"
+}
+
+func sortedKeys(values map[string]bool) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,182 @@
+package codegen
+
+import (
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/lifeomic/phc-sdk-go/graphql"
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+const TEST_SCHEMA = `
+enum Color { DARK_RED BLUE }
+
+scalar Upload
+
+interface Named { name: String! }
+
+type Thing implements Named {
+  id: ID!
+  name: String!
+  color: Color
+  tags: [String!]!
+  parts: [Thing!]
+}
+
+input ThingInput {
+  name: String!
+  color: Color
+  picture: Upload
+}
+
+type Query {
+  thing(id: ID!): Named
+}
+
+type Mutation {
+  createThing(input: ThingInput!): Thing!
+}
+`
+
+func TestGenerate(t *testing.T) {
+	schema, err := graphql.LoadSchema("test", TEST_SCHEMA)
+	if err != nil {
+		t.Fatal("Unexpected error", err)
+	}
+	generated, err := Generate(schema, []*ast.Source{{Name: "things.graphql", Input: `
+		fragment ThingParts on Thing { id color parts { id } }
+		query GetThing($id: ID!) {
+			thing(id: $id) {
+				__typename
+				name
+				... on Thing { ...ThingParts tags }
+			}
+		}
+		mutation CreateThing($input: ThingInput!) {
+			created: createThing(input: $input) { id }
+		}
+	`}}, Config{Package: "things", ClientImport: "github.com/lifeomic/phc-sdk-go/client"})
+	if err != nil {
+		t.Fatal("Unexpected error", err)
+	}
+	code := string(generated)
+	expected := []string{
+		"package things",
+		`phc "github.com/lifeomic/phc-sdk-go/client"`,
+		"func GetThing(ctx context.Context, client phc.Querier, variables GetThingVariables) (*GetThingResponse, error)",
+		"Thing *GetThingThing `json:\"thing\"`",
+		"Typename string `json:\"__typename\"`",
+		"Color *Color `json:\"color\"`",
+		"Tags []string `json:\"tags\"`",
+		"Parts []GetThingThingParts `json:\"parts\"`",
+		"Created CreateThingCreated `json:\"created\"`",
+		"Color phc.Optional[Color] `json:\"color\"`",
+		"Picture phc.Optional[phc.Upload] `json:\"picture\"`",
+		"ColorDarkRed Color = \"DARK_RED\"",
+		"fragment ThingParts on Thing",
+	}
+	for _, snippet := range expected {
+		if !strings.Contains(strings.Join(strings.Fields(code), " "), strings.Join(strings.Fields(snippet), " ")) {
+			t.Fatalf("Generated code is missing %s\n%s", snippet, code)
+		}
+	}
+}
+
+func TestGenerateUsesMappedTypes(t *testing.T) {
+	schema, err := graphql.LoadSchema("test", TEST_SCHEMA)
+	if err != nil {
+		t.Fatal("Unexpected error", err)
+	}
+	generated, err := Generate(schema, []*ast.Source{{Name: "things.graphql", Input: `
+		query GetThing($id: ID!) { thing(id: $id) { ... on Thing { color } } }
+		mutation CreateThing($input: ThingInput!) { createThing(input: $input) { id } }
+	`}}, Config{Package: "things", Types: map[string]string{"Color": "Shade", "ThingInput": "NewThing"}})
+	if err != nil {
+		t.Fatal("Unexpected error", err)
+	}
+	code := string(generated)
+	for _, snippet := range []string{"Color *Shade `json:\"color\"`", "Input NewThing `json:\"input\"`"} {
+		if !strings.Contains(code, snippet) {
+			t.Fatalf("Generated code is missing %s\n%s", snippet, code)
+		}
+	}
+	for _, declaration := range []string{"type Color ", "type ThingInput ", "type Shade ", "type NewThing "} {
+		if strings.Contains(code, declaration) {
+			t.Fatalf("Mapped types should not be declared: %s\n%s", declaration, code)
+		}
+	}
+}
+
+func TestGenerateDeclaresFragmentTypes(t *testing.T) {
+	schema, err := graphql.LoadSchema("test", TEST_SCHEMA)
+	if err != nil {
+		t.Fatal("Unexpected error", err)
+	}
+	generated, err := Generate(schema, []*ast.Source{{Name: "things.graphql", Input: `
+		fragment ThingFields on Thing { id color }
+		query GetThing($id: ID!) { thing(id: $id) { ... on Thing { parts { ...ThingFields } } } }
+		mutation CreateThing($input: ThingInput!) { createThing(input: $input) { ...ThingFields } }
+	`}}, Config{Package: "things"})
+	if err != nil {
+		t.Fatal("Unexpected error", err)
+	}
+	code := string(generated)
+	for _, snippet := range []string{"Parts []ThingFields `json:\"parts\"`", "CreateThing ThingFields `json:\"createThing\"`", "Color *Color `json:\"color\"`"} {
+		if !strings.Contains(code, snippet) {
+			t.Fatalf("Generated code is missing %s\n%s", snippet, code)
+		}
+	}
+	if strings.Count(code, "type ThingFields struct") != 1 {
+		t.Fatalf("The fragment type should be declared once\n%s", code)
+	}
+}
+
+func TestGenerateRejectsInvalidOperations(t *testing.T) {
+	schema, err := graphql.LoadSchema("test", TEST_SCHEMA)
+	if err != nil {
+		t.Fatal("Unexpected error", err)
+	}
+	_, err = Generate(schema, []*ast.Source{{Name: "things.graphql", Input: `
+		query GetThing($id: ID!) { thing(id: $id) { nmae } }
+	`}}, Config{Package: "things"})
+	if err == nil || !strings.Contains(err.Error(), "nmae") {
+		t.Fatal("Expected a validation error", err)
+	}
+}
+
+// The generated code must match its schema snapshots and operations, run
+// `go generate ./...` when this fails
+func TestClientCodeIsUpToDate(t *testing.T) {
+	cases := []struct {
+		schema     string
+		operations string
+		generated  string
+		config     Config
+	}{
+		{"../schema/marketplace.graphql", "../client/operations/marketplace.graphql", "../client/marketplace_gen.go", Config{Package: "client", Scalars: map[string]string{"JSON": "map[string]string"}}},
+		{"../schema/app-store.graphql", "../client/operations/app-store.graphql", "../client/appStore_gen.go", Config{Package: "client"}},
+		{"../schema/marketplace.graphql", "../query.graphql", "../cmd/query_gen.go", Config{Package: "main", ClientImport: "github.com/lifeomic/phc-sdk-go/client"}},
+	}
+	for _, c := range cases {
+		schema, err := graphql.LoadSchemaFile(c.schema)
+		if err != nil {
+			t.Fatal("Unexpected error", err)
+		}
+		operations, err := ioutil.ReadFile(c.operations)
+		if err != nil {
+			t.Fatal("Unexpected error", err)
+		}
+		generated, err := Generate(schema, []*ast.Source{{Name: c.operations, Input: string(operations)}}, c.config)
+		if err != nil {
+			t.Fatal("Unexpected error", err)
+		}
+		committed, err := ioutil.ReadFile(c.generated)
+		if err != nil {
+			t.Fatal("Unexpected error", err)
+		}
+		if string(generated) != string(committed) {
+			t.Fatalf("%s is out of date, run go generate ./...", c.generated)
+		}
+	}
+}
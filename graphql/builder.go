@@ -0,0 +1,329 @@
+package graphql
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/parser"
+)
+
+// Selection is a field or an inline fragment in a selection set
+type Selection interface {
+	render(b *strings.Builder, depth int, vars *variableSet)
+}
+
+// Operation builds a query or mutation document:
+//
+//	query := graphql.Query("GetModule",
+//		graphql.Field("myModule", graphql.Field("title")).
+//			Arg("moduleId", graphql.Var("id", "ID!")),
+//	)
+//
+// Variable declarations are derived from the variables used in arguments, in
+// the order they first appear.
+type Operation struct {
+	kind       string
+	name       string
+	selections []Selection
+}
+
+func Query(name string, selections ...Selection) *Operation {
+	return &Operation{kind: "query", name: name, selections: selections}
+}
+
+func Mutation(name string, selections ...Selection) *Operation {
+	return &Operation{kind: "mutation", name: name, selections: selections}
+}
+
+// Select adds selections to the root of the operation
+func (o *Operation) Select(selections ...Selection) *Operation {
+	o.selections = append(o.selections, selections...)
+	return o
+}
+
+// Build renders the document. The result is parsed again so that invalid
+// names or types are reported here rather than by the service.
+func (o *Operation) Build() (string, error) {
+	if len(o.selections) == 0 {
+		return "", fmt.Errorf("%s %s has no selections", o.kind, o.name)
+	}
+	vars := &variableSet{types: map[string]string{}}
+	if o.name != "" {
+		vars.checkName(o.name)
+	}
+	var body strings.Builder
+	renderSelections(&body, o.selections, 0, vars)
+	if vars.err != nil {
+		return "", vars.err
+	}
+
+	var b strings.Builder
+	b.WriteString(o.kind)
+	if o.name != "" {
+		b.WriteString(" " + o.name)
+	}
+	if len(vars.names) > 0 {
+		declarations := make([]string, len(vars.names))
+		for i, name := range vars.names {
+			declarations[i] = "$" + name + ": " + vars.types[name]
+		}
+		b.WriteString("(" + strings.Join(declarations, ", ") + ")")
+	}
+	b.WriteString(body.String())
+	b.WriteString("\n")
+
+	document := b.String()
+	if _, err := parser.ParseQuery(&ast.Source{Name: o.name, Input: document}); err != nil {
+		return "", err
+	}
+	return document, nil
+}
+
+// MustBuild is like Build but panics on error, for package level documents
+func (o *Operation) MustBuild() string {
+	document, err := o.Build()
+	if err != nil {
+		panic(err)
+	}
+	return document
+}
+
+// FieldSelection is a field with optional alias, arguments and selections
+type FieldSelection struct {
+	name       string
+	alias      string
+	arguments  []argument
+	selections []Selection
+}
+
+type argument struct {
+	name  string
+	value interface{}
+}
+
+func Field(name string, selections ...Selection) *FieldSelection {
+	return &FieldSelection{name: name, selections: selections}
+}
+
+// Fields selects several scalar fields at once
+func Fields(names ...string) []Selection {
+	selections := make([]Selection, len(names))
+	for i, name := range names {
+		selections[i] = Field(name)
+	}
+	return selections
+}
+
+func (f *FieldSelection) Alias(alias string) *FieldSelection {
+	f.alias = alias
+	return f
+}
+
+// Arg adds an argument. The value is a Variable, an Enum or any value that
+// can be written as a GraphQL literal: strings, numbers, booleans, nil,
+// slices, maps with string keys and structs with json tags.
+func (f *FieldSelection) Arg(name string, value interface{}) *FieldSelection {
+	f.arguments = append(f.arguments, argument{name: name, value: value})
+	return f
+}
+
+func (f *FieldSelection) Select(selections ...Selection) *FieldSelection {
+	f.selections = append(f.selections, selections...)
+	return f
+}
+
+func (f *FieldSelection) render(b *strings.Builder, depth int, vars *variableSet) {
+	vars.checkName(f.name)
+	indent(b, depth)
+	if f.alias != "" {
+		vars.checkName(f.alias)
+		b.WriteString(f.alias + ": ")
+	}
+	b.WriteString(f.name)
+	if len(f.arguments) > 0 {
+		b.WriteString("(")
+		for i, arg := range f.arguments {
+			if i > 0 {
+				b.WriteString(", ")
+			}
+			vars.checkName(arg.name)
+			b.WriteString(arg.name + ": ")
+			writeValue(b, arg.value, vars)
+		}
+		b.WriteString(")")
+	}
+	renderSelections(b, f.selections, depth, vars)
+}
+
+type inlineFragment struct {
+	typeName   string
+	selections []Selection
+}
+
+// On selects fields only when the object has the given type, for example
+// `... on AppTile`
+func On(typeName string, selections ...Selection) Selection {
+	return &inlineFragment{typeName: typeName, selections: selections}
+}
+
+func (f *inlineFragment) render(b *strings.Builder, depth int, vars *variableSet) {
+	vars.checkName(f.typeName)
+	indent(b, depth)
+	b.WriteString("... on " + f.typeName)
+	renderSelections(b, f.selections, depth, vars)
+}
+
+// Variable is an argument value provided with the operation's variables
+type Variable struct {
+	Name string
+	Type string
+}
+
+// Var references the variable name of type typ, such as `ID!` or `[String!]`
+func Var(name string, typ string) Variable {
+	return Variable{Name: name, Type: typ}
+}
+
+// Enum is an argument value written without quotes
+type Enum string
+
+type variableSet struct {
+	names []string
+	types map[string]string
+	err   error
+}
+
+func (v *variableSet) use(variable Variable) {
+	v.checkName(variable.Name)
+	existing, ok := v.types[variable.Name]
+	if !ok {
+		v.names = append(v.names, variable.Name)
+		v.types[variable.Name] = variable.Type
+		return
+	}
+	if existing != variable.Type && v.err == nil {
+		v.err = fmt.Errorf("variable $%s is used as both %s and %s", variable.Name, existing, variable.Type)
+	}
+}
+
+func (v *variableSet) fail(err error) {
+	if v.err == nil {
+		v.err = err
+	}
+}
+
+// checkName catches names that would still parse but change the meaning of
+// the document, such as a field name containing a space
+func (v *variableSet) checkName(name string) {
+	for i, ch := range name {
+		if ch == '_' || ch >= 'a' && ch <= 'z' || ch >= 'A' && ch <= 'Z' || i > 0 && ch >= '0' && ch <= '9' {
+			continue
+		}
+		v.fail(fmt.Errorf("%q is not a valid GraphQL name", name))
+		return
+	}
+	if name == "" {
+		v.fail(fmt.Errorf("names must not be empty"))
+	}
+}
+
+func renderSelections(b *strings.Builder, selections []Selection, depth int, vars *variableSet) {
+	if len(selections) == 0 {
+		return
+	}
+	b.WriteString(" {\n")
+	for _, selection := range selections {
+		selection.render(b, depth+1, vars)
+		b.WriteString("\n")
+	}
+	indent(b, depth)
+	b.WriteString("}")
+}
+
+func indent(b *strings.Builder, depth int) {
+	b.WriteString(strings.Repeat("\t", depth))
+}
+
+func writeValue(b *strings.Builder, value interface{}, vars *variableSet) {
+	switch value := value.(type) {
+	case Variable:
+		vars.use(value)
+		b.WriteString("$" + value.Name)
+		return
+	case Enum:
+		b.WriteString(string(value))
+		return
+	case nil:
+		b.WriteString("null")
+		return
+	}
+
+	rv := reflect.ValueOf(value)
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Array:
+		b.WriteString("[")
+		for i := 0; i < rv.Len(); i++ {
+			if i > 0 {
+				b.WriteString(", ")
+			}
+			writeValue(b, rv.Index(i).Interface(), vars)
+		}
+		b.WriteString("]")
+	case reflect.Map:
+		keys := make([]string, 0, rv.Len())
+		values := map[string]interface{}{}
+		for _, key := range rv.MapKeys() {
+			if key.Kind() != reflect.String {
+				vars.fail(fmt.Errorf("map keys of argument values must be strings, not %s", key.Type()))
+				return
+			}
+			keys = append(keys, key.String())
+			values[key.String()] = rv.MapIndex(key).Interface()
+		}
+		sort.Strings(keys)
+		writeObject(b, keys, values, vars)
+	case reflect.Ptr, reflect.Interface:
+		if rv.IsNil() {
+			b.WriteString("null")
+			return
+		}
+		writeValue(b, rv.Elem().Interface(), vars)
+	case reflect.Struct:
+		// structs are written with their json field names, nested variables
+		// are only supported in maps
+		encoded, err := json.Marshal(value)
+		if err != nil {
+			vars.fail(err)
+			return
+		}
+		var decoded interface{}
+		if err := json.Unmarshal(encoded, &decoded); err != nil {
+			vars.fail(err)
+			return
+		}
+		writeValue(b, decoded, vars)
+	default:
+		encoded, err := json.Marshal(value)
+		if err != nil {
+			vars.fail(err)
+			return
+		}
+		b.Write(encoded)
+	}
+}
+
+func writeObject(b *strings.Builder, keys []string, values map[string]interface{}, vars *variableSet) {
+	b.WriteString("{")
+	for i, key := range keys {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		b.WriteString(key + ": ")
+		writeValue(b, values[key], vars)
+	}
+	b.WriteString("}")
+}
@@ -0,0 +1,119 @@
+package graphql
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestBuildQuery(t *testing.T) {
+	query := Query("GetPublishedModule",
+		Field("myModule").
+			Arg("moduleId", Var("id", "ID!")).
+			Arg("version", Var("version", "String")).
+			Select(Fields("title", "description", "version")...).
+			Select(
+				Field("source", On("AppTile", Field("id"))),
+				Field("iconV2", Fields("url", "fileName", "fileExtension")...),
+			),
+	)
+	document, err := query.Build()
+	if err != nil {
+		t.Fatal("Unexpected error", err)
+	}
+	expected := `query GetPublishedModule($id: ID!, $version: String) {
+	myModule(moduleId: $id, version: $version) {
+		title
+		description
+		version
+		source {
+			... on AppTile {
+				id
+			}
+		}
+		iconV2 {
+			url
+			fileName
+			fileExtension
+		}
+	}
+}
+`
+	if document != expected {
+		t.Fatal("Unexpected document", document)
+	}
+
+	validator, err := LoadValidator("../schema/marketplace.graphql")
+	if err != nil {
+		t.Fatal("Could not load schema", err)
+	}
+	if err := validator.ValidateDocument(document); err != nil {
+		t.Fatal("Built document does not match the schema", err)
+	}
+}
+
+func TestBuildMutationWithLiterals(t *testing.T) {
+	type imageInput struct {
+		Type     Enum   `json:"type"`
+		ModuleId string `json:"moduleId"`
+	}
+	mutation := Mutation("Upload",
+		Field("finalizeUpload",
+			Field("id"),
+		).Alias("upload").Arg("input", map[string]interface{}{
+			"id":     Var("uploadId", "ID!"),
+			"tags":   []string{"a", "b"},
+			"weight": 1.5,
+			"parent": nil,
+			"image":  imageInput{Type: "ICON", ModuleId: "module"},
+		}),
+		Field("again", Field("id")).Arg("id", Var("uploadId", "ID!")),
+	)
+	document, err := mutation.Build()
+	if err != nil {
+		t.Fatal("Unexpected error", err)
+	}
+	expected := `mutation Upload($uploadId: ID!) {
+	upload: finalizeUpload(input: {id: $uploadId, image: {moduleId: "module", type: "ICON"}, parent: null, tags: ["a", "b"], weight: 1.5}) {
+		id
+	}
+	again(id: $uploadId) {
+		id
+	}
+}
+`
+	if document != expected {
+		t.Fatal("Unexpected document", document)
+	}
+}
+
+func TestBuildEnumArgument(t *testing.T) {
+	document := Query("", Field("modules", Field("id")).Arg("category", Enum("APP_TILE"))).MustBuild()
+	if !strings.Contains(document, "modules(category: APP_TILE)") {
+		t.Fatal("Enums should not be quoted", document)
+	}
+}
+
+func TestBuildErrors(t *testing.T) {
+	_, err := Query("Conflict",
+		Field("a", Field("id")).Arg("id", Var("id", "ID!")),
+		Field("b", Field("id")).Arg("id", Var("id", "String")),
+	).Build()
+	if err == nil || !strings.Contains(err.Error(), "variable $id is used as both ID! and String") {
+		t.Fatal("Expected a variable type conflict", err)
+	}
+
+	_, err = Query("Empty").Build()
+	if err == nil {
+		t.Fatal("Expected an error for an operation without selections")
+	}
+
+	_, err = Query("Invalid", Field("bad name")).Build()
+	if err == nil || !strings.Contains(err.Error(), `"bad name" is not a valid GraphQL name`) {
+		t.Fatal("Expected an invalid name error", err)
+	}
+
+	_, err = Query("InvalidType", Field("a").Arg("id", Var("id", "ID!!"))).Build()
+	if err == nil {
+		t.Fatal("Expected a syntax error for the variable type")
+	}
+}
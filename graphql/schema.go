@@ -0,0 +1,313 @@
+// Package graphql loads schema snapshots of PHC services, either as SDL or as
+// the JSON result of an introspection query.
+package graphql
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/vektah/gqlparser/v2"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/formatter"
+)
+
+const INTROSPECTION_QUERY = `
+  query IntrospectionQuery {
+    __schema {
+      queryType { name }
+      mutationType { name }
+      subscriptionType { name }
+      types { ...FullType }
+    }
+  }
+
+  fragment FullType on __Type {
+    kind
+    name
+    description
+    fields(includeDeprecated: true) {
+      name
+      description
+      args { ...InputValue }
+      type { ...TypeRef }
+      isDeprecated
+      deprecationReason
+    }
+    inputFields { ...InputValue }
+    interfaces { ...TypeRef }
+    enumValues(includeDeprecated: true) {
+      name
+      description
+      isDeprecated
+      deprecationReason
+    }
+    possibleTypes { ...TypeRef }
+  }
+
+  fragment InputValue on __InputValue {
+    name
+    description
+    type { ...TypeRef }
+    defaultValue
+  }
+
+  fragment TypeRef on __Type {
+    kind
+    name
+    ofType {
+      kind
+      name
+      ofType {
+        kind
+        name
+        ofType {
+          kind
+          name
+          ofType {
+            kind
+            name
+          }
+        }
+      }
+    }
+  }
+`
+
+// LoadSchemaFile loads a schema snapshot. Files ending in .json are read as
+// introspection results, anything else as SDL.
+func LoadSchemaFile(path string) (*ast.Schema, error) {
+	raw, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		return SchemaFromIntrospection(raw)
+	}
+	return LoadSchema(path, string(raw))
+}
+
+// LoadSchema parses and validates SDL, name is used in error messages
+func LoadSchema(name string, sdl string) (*ast.Schema, error) {
+	schema, err := gqlparser.LoadSchema(&ast.Source{Name: name, Input: sdl})
+	if err != nil {
+		return nil, err
+	}
+	return schema, nil
+}
+
+// SchemaFromIntrospection builds a schema from the JSON result of
+// INTROSPECTION_QUERY, with or without the surrounding `data` object
+func SchemaFromIntrospection(raw []byte) (*ast.Schema, error) {
+	sdl, err := IntrospectionToSDL(raw)
+	if err != nil {
+		return nil, err
+	}
+	return LoadSchema("introspection", sdl)
+}
+
+// FormatSchema renders a schema as SDL, leaving out built in types
+func FormatSchema(schema *ast.Schema) string {
+	trimmed := *schema
+	trimmed.Types = map[string]*ast.Definition{}
+	for name, definition := range schema.Types {
+		if !definition.BuiltIn {
+			trimmed.Types[name] = definition
+		}
+	}
+	trimmed.Directives = map[string]*ast.DirectiveDefinition{}
+	for name, directive := range schema.Directives {
+		if directive.Position == nil || directive.Position.Src == nil || !directive.Position.Src.BuiltIn {
+			trimmed.Directives[name] = directive
+		}
+	}
+	buffer := &bytes.Buffer{}
+	formatter.NewFormatter(buffer).FormatSchema(&trimmed)
+	return buffer.String()
+}
+
+type introspectionTypeRef struct {
+	Kind   string
+	Name   *string
+	OfType *introspectionTypeRef
+}
+
+type introspectionInputValue struct {
+	Name         string
+	Description  *string
+	Type         introspectionTypeRef
+	DefaultValue *string
+}
+
+type introspectionType struct {
+	Kind        string
+	Name        string
+	Description *string
+	Fields      []struct {
+		Name              string
+		Description       *string
+		Args              []introspectionInputValue
+		Type              introspectionTypeRef
+		IsDeprecated      bool
+		DeprecationReason *string
+	}
+	InputFields []introspectionInputValue
+	Interfaces  []introspectionTypeRef
+	EnumValues  []struct {
+		Name              string
+		Description       *string
+		IsDeprecated      bool
+		DeprecationReason *string
+	}
+	PossibleTypes []introspectionTypeRef
+}
+
+type introspectionSchema struct {
+	QueryType        *struct{ Name string }
+	MutationType     *struct{ Name string }
+	SubscriptionType *struct{ Name string }
+	Types            []introspectionType
+}
+
+var builtInScalars = map[string]bool{"String": true, "Int": true, "Float": true, "Boolean": true, "ID": true}
+
+// IntrospectionToSDL converts the JSON result of INTROSPECTION_QUERY to SDL
+func IntrospectionToSDL(raw []byte) (string, error) {
+	var result struct {
+		Data *struct {
+			Schema *introspectionSchema `json:"__schema"`
+		}
+		Schema *introspectionSchema `json:"__schema"`
+	}
+	if err := json.Unmarshal(raw, &result); err != nil {
+		return "", fmt.Errorf("failed to parse introspection result: %w", err)
+	}
+	schema := result.Schema
+	if schema == nil && result.Data != nil {
+		schema = result.Data.Schema
+	}
+	if schema == nil {
+		return "", fmt.Errorf("introspection result does not contain __schema")
+	}
+
+	types := schema.Types
+	sort.Slice(types, func(i, j int) bool { return types[i].Name < types[j].Name })
+
+	sdl := &strings.Builder{}
+	roots := []string{}
+	if schema.QueryType != nil && schema.QueryType.Name != "Query" {
+		roots = append(roots, "  query: "+schema.QueryType.Name)
+	}
+	if schema.MutationType != nil && schema.MutationType.Name != "Mutation" {
+		roots = append(roots, "  mutation: "+schema.MutationType.Name)
+	}
+	if schema.SubscriptionType != nil && schema.SubscriptionType.Name != "Subscription" {
+		roots = append(roots, "  subscription: "+schema.SubscriptionType.Name)
+	}
+	if len(roots) > 0 {
+		fmt.Fprintf(sdl, "schema {\n%s\n}\n\n", strings.Join(roots, "\n"))
+	}
+
+	for _, t := range types {
+		if strings.HasPrefix(t.Name, "__") || builtInScalars[t.Name] {
+			continue
+		}
+		writeDescription(sdl, t.Description, "")
+		switch t.Kind {
+		case "SCALAR":
+			fmt.Fprintf(sdl, "scalar %s\n\n", t.Name)
+		case "ENUM":
+			fmt.Fprintf(sdl, "enum %s {\n", t.Name)
+			for _, value := range t.EnumValues {
+				writeDescription(sdl, value.Description, "  ")
+				fmt.Fprintf(sdl, "  %s%s\n", value.Name, deprecated(value.IsDeprecated, value.DeprecationReason))
+			}
+			sdl.WriteString("}\n\n")
+		case "INPUT_OBJECT":
+			fmt.Fprintf(sdl, "input %s {\n", t.Name)
+			for _, field := range t.InputFields {
+				writeDescription(sdl, field.Description, "  ")
+				fmt.Fprintf(sdl, "  %s\n", inputValue(field))
+			}
+			sdl.WriteString("}\n\n")
+		case "UNION":
+			members := []string{}
+			for _, member := range t.PossibleTypes {
+				members = append(members, typeRef(member))
+			}
+			fmt.Fprintf(sdl, "union %s = %s\n\n", t.Name, strings.Join(members, " | "))
+		case "OBJECT", "INTERFACE":
+			keyword := "type"
+			if t.Kind == "INTERFACE" {
+				keyword = "interface"
+			}
+			fmt.Fprintf(sdl, "%s %s", keyword, t.Name)
+			if len(t.Interfaces) > 0 {
+				interfaces := []string{}
+				for _, i := range t.Interfaces {
+					interfaces = append(interfaces, typeRef(i))
+				}
+				fmt.Fprintf(sdl, " implements %s", strings.Join(interfaces, " & "))
+			}
+			sdl.WriteString(" {\n")
+			for _, field := range t.Fields {
+				writeDescription(sdl, field.Description, "  ")
+				args := ""
+				if len(field.Args) > 0 {
+					values := []string{}
+					for _, arg := range field.Args {
+						values = append(values, inputValue(arg))
+					}
+					args = "(" + strings.Join(values, ", ") + ")"
+				}
+				fmt.Fprintf(sdl, "  %s%s: %s%s\n", field.Name, args, typeRef(field.Type), deprecated(field.IsDeprecated, field.DeprecationReason))
+			}
+			sdl.WriteString("}\n\n")
+		default:
+			return "", fmt.Errorf("unknown kind %s for type %s", t.Kind, t.Name)
+		}
+	}
+	return sdl.String(), nil
+}
+
+func typeRef(ref introspectionTypeRef) string {
+	switch ref.Kind {
+	case "NON_NULL":
+		return typeRef(*ref.OfType) + "!"
+	case "LIST":
+		return "[" + typeRef(*ref.OfType) + "]"
+	default:
+		return *ref.Name
+	}
+}
+
+func inputValue(value introspectionInputValue) string {
+	result := value.Name + ": " + typeRef(value.Type)
+	if value.DefaultValue != nil {
+		result += " = " + *value.DefaultValue
+	}
+	return result
+}
+
+func deprecated(isDeprecated bool, reason *string) string {
+	if !isDeprecated {
+		return ""
+	}
+	if reason == nil {
+		return " @deprecated"
+	}
+	encoded, _ := json.Marshal(*reason)
+	return fmt.Sprintf(" @deprecated(reason: %s)", encoded)
+}
+
+func writeDescription(sdl *strings.Builder, description *string, indent string) {
+	if description == nil || *description == "" {
+		return
+	}
+	encoded, _ := json.Marshal(*description)
+	fmt.Fprintf(sdl, "%s%s\n", indent, encoded)
+}
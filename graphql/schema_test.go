@@ -0,0 +1,84 @@
+package graphql
+
+import (
+	"strings"
+	"testing"
+)
+
+const TEST_INTROSPECTION = `{
+  "data": {
+    "__schema": {
+      "queryType": { "name": "Query" },
+      "mutationType": null,
+      "subscriptionType": null,
+      "types": [
+        {
+          "kind": "OBJECT",
+          "name": "Query",
+          "fields": [
+            {
+              "name": "thing",
+              "args": [
+                { "name": "id", "type": { "kind": "NON_NULL", "name": null, "ofType": { "kind": "SCALAR", "name": "ID" } }, "defaultValue": null }
+              ],
+              "type": { "kind": "OBJECT", "name": "Thing" },
+              "isDeprecated": false
+            }
+          ]
+        },
+        {
+          "kind": "OBJECT",
+          "name": "Thing",
+          "interfaces": [],
+          "fields": [
+            { "name": "name", "args": [], "type": { "kind": "SCALAR", "name": "String" }, "isDeprecated": true, "deprecationReason": "Use title" },
+            { "name": "kind", "args": [], "type": { "kind": "NON_NULL", "name": null, "ofType": { "kind": "ENUM", "name": "Kind" } }, "isDeprecated": false },
+            { "name": "tags", "args": [], "type": { "kind": "LIST", "name": null, "ofType": { "kind": "SCALAR", "name": "String" } }, "isDeprecated": false }
+          ]
+        },
+        {
+          "kind": "ENUM",
+          "name": "Kind",
+          "description": "The kind of thing",
+          "enumValues": [ { "name": "SMALL", "isDeprecated": false }, { "name": "LARGE", "isDeprecated": false } ]
+        },
+        { "kind": "SCALAR", "name": "String" },
+        { "kind": "SCALAR", "name": "ID" },
+        { "kind": "OBJECT", "name": "__Type", "fields": [] }
+      ]
+    }
+  }
+}`
+
+func TestSchemaFromIntrospection(t *testing.T) {
+	schema, err := SchemaFromIntrospection([]byte(TEST_INTROSPECTION))
+	if err != nil {
+		t.Fatal("Unexpected error", err)
+	}
+	thing := schema.Types["Thing"]
+	if thing == nil || thing.Fields.ForName("kind").Type.String() != "Kind!" {
+		t.Fatal("Did not load the Thing type", thing)
+	}
+	if thing.Fields.ForName("name").Directives.ForName("deprecated") == nil {
+		t.Fatal("Did not keep the deprecation")
+	}
+	if schema.Query.Fields.ForName("thing").Arguments.ForName("id").Type.String() != "ID!" {
+		t.Fatal("Did not load the query arguments")
+	}
+
+	sdl := FormatSchema(schema)
+	if !strings.Contains(sdl, "enum Kind") || strings.Contains(sdl, "__Type") {
+		t.Fatal("Did not format the schema without built in types", sdl)
+	}
+	if _, err := LoadSchema("formatted", sdl); err != nil {
+		t.Fatal("Formatted schema should load again", err, sdl)
+	}
+}
+
+func TestLoadSchemaFile(t *testing.T) {
+	for _, file := range []string{"../schema/marketplace.graphql", "../schema/app-store.graphql"} {
+		if _, err := LoadSchemaFile(file); err != nil {
+			t.Fatal("Could not load schema snapshot", file, err)
+		}
+	}
+}
@@ -0,0 +1,127 @@
+package graphql
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+	"github.com/vektah/gqlparser/v2/parser"
+	"github.com/vektah/gqlparser/v2/validator"
+)
+
+// Validator checks operations against a schema snapshot before they are sent:
+// selected fields must exist, arguments and variables must have the right
+// types, every variable must be defined and used and every fragment spread
+// must refer to a fragment that applies.
+//
+// Documents are only parsed and validated once, so a Validator can be used
+// for every request at runtime.
+type Validator struct {
+	schema    *ast.Schema
+	documents sync.Map
+}
+
+type validatedDocument struct {
+	document *ast.QueryDocument
+	err      error
+}
+
+func NewValidator(schema *ast.Schema) *Validator {
+	return &Validator{schema: schema}
+}
+
+// LoadValidator creates a validator for a schema snapshot, see LoadSchemaFile
+func LoadValidator(path string) (*Validator, error) {
+	schema, err := LoadSchemaFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return NewValidator(schema), nil
+}
+
+func (v *Validator) Schema() *ast.Schema {
+	return v.schema
+}
+
+// ValidateDocument parses and validates a document without variables
+func (v *Validator) ValidateDocument(query string) error {
+	_, err := v.document(query)
+	return err
+}
+
+// Validate validates the document and, for documents with a single
+// operation, the variable values sent with it
+func (v *Validator) Validate(query string, variables map[string]interface{}) error {
+	document, err := v.document(query)
+	if err != nil {
+		return err
+	}
+	if len(document.Operations) != 1 {
+		return nil
+	}
+	return v.validateVariables(document.Operations[0], variables)
+}
+
+// ValidateOperation validates the document and the variable values of the
+// named operation, for documents that define several
+func (v *Validator) ValidateOperation(query string, operationName string, variables map[string]interface{}) error {
+	document, err := v.document(query)
+	if err != nil {
+		return err
+	}
+	operation := document.Operations.ForName(operationName)
+	if operation == nil {
+		return fmt.Errorf("operation %s is not defined in the document", operationName)
+	}
+	return v.validateVariables(operation, variables)
+}
+
+func (v *Validator) document(query string) (*ast.QueryDocument, error) {
+	if cached, ok := v.documents.Load(query); ok {
+		validated := cached.(validatedDocument)
+		return validated.document, validated.err
+	}
+	document, err := parser.ParseQuery(&ast.Source{Name: "operation", Input: query})
+	if err == nil {
+		if errs := validator.Validate(v.schema, document); len(errs) > 0 {
+			err = errs
+		}
+	}
+	if err != nil {
+		document = nil
+	}
+	v.documents.Store(query, validatedDocument{document: document, err: err})
+	return document, err
+}
+
+// validateVariables normalizes the values through JSON first, so typed values
+// such as structs and pointers are checked the way the service will see them
+func (v *Validator) validateVariables(operation *ast.OperationDefinition, variables map[string]interface{}) (err error) {
+	encoded, err := json.Marshal(variables)
+	if err != nil {
+		return err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(encoded))
+	decoder.UseNumber()
+	var normalized map[string]interface{}
+	if err := decoder.Decode(&normalized); err != nil {
+		return err
+	}
+	if normalized == nil {
+		normalized = map[string]interface{}{}
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("could not validate variables: %v", r)
+		}
+	}()
+	_, err = validator.VariableValues(v.schema, operation, normalized)
+	if gqlErr, ok := err.(*gqlerror.Error); ok && gqlErr == nil {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,110 @@
+package graphql
+
+import (
+	"strings"
+	"testing"
+)
+
+const VALIDATOR_SCHEMA = `
+type Query {
+  thing(id: ID!): Thing
+}
+
+type Mutation {
+  rename(input: RenameInput!): Thing
+}
+
+input RenameInput {
+  id: ID!
+  name: String!
+  count: Int
+}
+
+type Thing {
+  id: ID!
+  name: String
+}
+
+type Other {
+  size: Int
+}
+`
+
+func testValidator(t *testing.T) *Validator {
+	schema, err := LoadSchema("test", VALIDATOR_SCHEMA)
+	if err != nil {
+		t.Fatal("Could not load schema", err)
+	}
+	return NewValidator(schema)
+}
+
+func TestValidateDocument(t *testing.T) {
+	validator := testValidator(t)
+
+	if err := validator.ValidateDocument(`query Get($id: ID!) { thing(id: $id) { id name } }`); err != nil {
+		t.Fatal("Unexpected error", err)
+	}
+
+	invalid := map[string]string{
+		`query { thing(id: "1") { title } }`:                          `Cannot query field "title" on type "Thing"`,
+		`query { thing(id: 1.5) { id } }`:                             `ID cannot represent`,
+		`query Get($id: ID!, $unused: Int) { thing(id: $id) { id } }`: `Variable "$unused" is never used`,
+		`query { thing(id: "1") { ...on Other { size } } }`:           `Fragment cannot be spread here`,
+		`query { thing(id: "1") { id }`:                               `Expected Name, found <EOF>`,
+	}
+	for query, expected := range invalid {
+		err := validator.ValidateDocument(query)
+		if err == nil || !strings.Contains(err.Error(), expected) {
+			t.Errorf("Expected %q for %s, got %v", expected, query, err)
+		}
+	}
+}
+
+func TestValidateVariableValues(t *testing.T) {
+	validator := testValidator(t)
+	query := `mutation Rename($input: RenameInput!) { rename(input: $input) { id } }`
+
+	type input struct {
+		Id    string `json:"id"`
+		Name  string `json:"name"`
+		Count *int   `json:"count,omitempty"`
+	}
+	if err := validator.Validate(query, map[string]interface{}{"input": input{Id: "1", Name: "name"}}); err != nil {
+		t.Fatal("Unexpected error", err)
+	}
+
+	invalid := []struct {
+		variables map[string]interface{}
+		expected  string
+	}{
+		{map[string]interface{}{}, "variable.input must be defined"},
+		{map[string]interface{}{"input": map[string]interface{}{"id": "1"}}, "variable.input.name must be defined"},
+		{map[string]interface{}{"input": map[string]interface{}{"id": "1", "name": "name", "count": "many"}}, "cannot use string as Int"},
+		{map[string]interface{}{"input": map[string]interface{}{"id": "1", "name": "name", "extra": true}}, "unknown field"},
+	}
+	for _, test := range invalid {
+		err := validator.Validate(query, test.variables)
+		if err == nil || !strings.Contains(err.Error(), test.expected) {
+			t.Errorf("Expected %q for %v, got %v", test.expected, test.variables, err)
+		}
+	}
+}
+
+func TestValidateOperation(t *testing.T) {
+	validator := testValidator(t)
+	document := `
+		query Get($id: ID!) { thing(id: $id) { id } }
+		mutation Rename($input: RenameInput!) { rename(input: $input) { id } }
+	`
+	if err := validator.ValidateOperation(document, "Get", map[string]interface{}{"id": "1"}); err != nil {
+		t.Fatal("Unexpected error", err)
+	}
+	err := validator.ValidateOperation(document, "Rename", map[string]interface{}{"id": "1"})
+	if err == nil || !strings.Contains(err.Error(), "variable.input must be defined") {
+		t.Fatal("Expected the variables of Rename to be validated", err)
+	}
+	err = validator.ValidateOperation(document, "Missing", nil)
+	if err == nil || err.Error() != "operation Missing is not defined in the document" {
+		t.Fatal("Expected an unknown operation error", err)
+	}
+}
@@ -0,0 +1,98 @@
+// Package prometheus adapts the client's MetricsRecorder interface to the
+// Prometheus client library.
+package prometheus
+
+import (
+	"github.com/lifeomic/phc-sdk-go/client"
+	prom "github.com/prometheus/client_golang/prometheus"
+)
+
+var labelNames = []string{"function_name", "path", "operation"}
+
+type Recorder struct {
+	invocations   *prom.CounterVec
+	errors        *prom.CounterVec
+	retries       *prom.CounterVec
+	latency       *prom.HistogramVec
+	requestBytes  *prom.HistogramVec
+	responseBytes *prom.HistogramVec
+}
+
+// NewRecorder creates the client metrics and registers them with the
+// registerer. Pass the recorder to client.WithMetrics to start collecting.
+func NewRecorder(registerer prom.Registerer, namespace string) (*Recorder, error) {
+	sizeBuckets := prom.ExponentialBuckets(256, 4, 8)
+	recorder := &Recorder{
+		invocations: prom.NewCounterVec(prom.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "phc_client",
+			Name:      "invocations_total",
+			Help:      "Number of lambda invocations made by the PHC client.",
+		}, labelNames),
+		errors: prom.NewCounterVec(prom.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "phc_client",
+			Name:      "errors_total",
+			Help:      "Number of failed lambda invocations by error class.",
+		}, append(labelNames, "class")),
+		retries: prom.NewCounterVec(prom.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "phc_client",
+			Name:      "retries_total",
+			Help:      "Number of retries made by the AWS SDK while invoking lambdas.",
+		}, labelNames),
+		latency: prom.NewHistogramVec(prom.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "phc_client",
+			Name:      "invocation_duration_seconds",
+			Help:      "Latency of lambda invocations.",
+			Buckets:   prom.DefBuckets,
+		}, labelNames),
+		requestBytes: prom.NewHistogramVec(prom.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "phc_client",
+			Name:      "request_size_bytes",
+			Help:      "Size of lambda request payloads.",
+			Buckets:   sizeBuckets,
+		}, labelNames),
+		responseBytes: prom.NewHistogramVec(prom.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "phc_client",
+			Name:      "response_size_bytes",
+			Help:      "Size of lambda response payloads.",
+			Buckets:   sizeBuckets,
+		}, labelNames),
+	}
+	collectors := []prom.Collector{
+		recorder.invocations,
+		recorder.errors,
+		recorder.retries,
+		recorder.latency,
+		recorder.requestBytes,
+		recorder.responseBytes,
+	}
+	for _, collector := range collectors {
+		if err := registerer.Register(collector); err != nil {
+			return nil, err
+		}
+	}
+	return recorder, nil
+}
+
+func (r *Recorder) RecordInvocation(invocation client.Invocation) {
+	labels := []string{
+		invocation.Labels.FunctionName,
+		invocation.Labels.Path,
+		invocation.Labels.Operation,
+	}
+	r.invocations.WithLabelValues(labels...).Inc()
+	if invocation.ErrorClass != "" {
+		r.errors.WithLabelValues(append(labels, invocation.ErrorClass)...).Inc()
+	}
+	if invocation.Retries > 0 {
+		r.retries.WithLabelValues(labels...).Add(float64(invocation.Retries))
+	}
+	r.latency.WithLabelValues(labels...).Observe(invocation.Duration.Seconds())
+	r.requestBytes.WithLabelValues(labels...).Observe(float64(invocation.RequestBytes))
+	r.responseBytes.WithLabelValues(labels...).Observe(float64(invocation.ResponseBytes))
+}
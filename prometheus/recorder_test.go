@@ -0,0 +1,35 @@
+package prometheus
+
+import (
+	"testing"
+	"time"
+
+	"github.com/lifeomic/phc-sdk-go/client"
+	prom "github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestRecordInvocation(t *testing.T) {
+	registry := prom.NewRegistry()
+	recorder, err := NewRecorder(registry, "test")
+	if err != nil {
+		t.Fatal("Unexpected error", err)
+	}
+	labels := client.MetricLabels{FunctionName: "some_lambda", Path: "/graphql", Operation: "GetThing"}
+	recorder.RecordInvocation(client.Invocation{Labels: labels, Duration: time.Second, Retries: 2})
+	recorder.RecordInvocation(client.Invocation{Labels: labels, ErrorClass: client.ERROR_CLASS_GRAPHQL})
+
+	if count := testutil.ToFloat64(recorder.invocations.WithLabelValues("some_lambda", "/graphql", "GetThing")); count != 2 {
+		t.Fatal("Did not count invocations", count)
+	}
+	if count := testutil.ToFloat64(recorder.errors.WithLabelValues("some_lambda", "/graphql", "GetThing", "graphql")); count != 1 {
+		t.Fatal("Did not count errors", count)
+	}
+	if count := testutil.ToFloat64(recorder.retries.WithLabelValues("some_lambda", "/graphql", "GetThing")); count != 2 {
+		t.Fatal("Did not count retries", count)
+	}
+
+	if _, err := NewRecorder(registry, "test"); err == nil {
+		t.Fatal("Expected registering the metrics twice to fail")
+	}
+}